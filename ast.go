@@ -1,8 +1,13 @@
 package boule
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"net/netip"
+	"strconv"
+	"time"
 
 	"github.com/victordeleau/boule/internal/prefixtree"
 )
@@ -10,6 +15,7 @@ import (
 // Data holds the variables that expressions are evaluated against.
 type Data struct {
 	prefixtree.Tree
+	env *environment
 }
 
 // NewData returns an empty Data store ready for variable insertion via Add.
@@ -17,21 +23,59 @@ func NewData() *Data {
 	return new(Data)
 }
 
+// environment holds the state of a single evaluation. It travels through the AST alongside the
+// caller's data, so that concurrent evaluations of the same expression never share it.
+type environment struct {
+	options *options
+	params  *Params
+	frame   *frame
+}
+
+// with returns a shallow copy of the data carrying the given evaluation environment.
+func (d *Data) with(env *environment) *Data {
+	if d == nil {
+		return &Data{env: env}
+	}
+	return &Data{Tree: d.Tree, env: env}
+}
+
+func (d *Data) environment() *environment {
+	if d == nil || d.env == nil {
+		return &environment{options: &options{}}
+	}
+	return d.env
+}
+
 /*
 Context-Free grammar
 
-expression         -> binary | suffixExpression
-suffixExpression   -> grouping | literal | unary
-literal            -> INTEGER | STRING | IDENT
+expression         -> binary | between | range | typeTest | suffixExpression
+suffixExpression   -> grouping | list | literal | unary | let
+literal            -> INTEGER | STRING | IDENT | PARAM | IP | CIDR | call
+call               -> IDENT OPEN (expression (COMMA expression)*)? CLOSE
 unary              -> NOT suffixExpression
-binary             -> expression operator suffixExpression
+binary             -> expression operator expression
+between            -> expression BETWEEN expression BETWEEN_AND expression
+range              -> expression (RANGE | RANGE_EXCLUSIVE) expression
+typeTest           -> expression IS IDENT
 grouping           -> OPEN expression CLOSE
-operator           -> EQUAL | NOT_EQUAL | LESS | LESS_EQUAL | GREATER | GREATER_EQUAL | AND | OR
+list               -> OPEN_LIST (expression (COMMA expression)*)? CLOSE_LIST
+let                -> LET binding (COMMA binding)* IN expression
+binding            -> IDENT ASSIGN expression
+operator           -> EQUAL | NOT_EQUAL | APPROX | LESS | LESS_EQUAL | GREATER | GREATER_EQUAL | IN | AND | OR
+                    | BIT_AND | BIT_OR | BIT_XOR | BIT_AND_NOT | SHIFT_LEFT | SHIFT_RIGHT | LIKE | GLOB | OF
+
+Binary operators are left-associative. From the loosest to the tightest binding: OR; AND; comparisons
+(including APPROX), IN, BETWEEN, LIKE, GLOB and IS; RANGE and RANGE_EXCLUSIVE; BIT_OR and BIT_XOR; BIT_AND, BIT_AND_NOT,
+SHIFT_LEFT and SHIFT_RIGHT; OF.
 */
 
-// Node represents an evaluable node in the expression AST.
+// Node represents an evaluable node in the expression AST. The span of a node in the expression
+// is given by Pos and End, which are byte offsets.
 type Node interface {
 	Evaluate(data *Data) (interface{}, error)
+	Pos() int // offset of the first character of the node
+	End() int // offset following the last character of the node
 }
 
 // GroupingExpression represents a parenthesized expression.
@@ -46,6 +90,15 @@ func (l *GroupingExpression) Evaluate(data *Data) (interface{}, error) {
 	return l.Node.Evaluate(data)
 }
 
+// Inner returns the parenthesized expression.
+func (l *GroupingExpression) Inner() Node { return l.Node }
+
+// Pos returns the offset of the opening parenthesis.
+func (l *GroupingExpression) Pos() int { return l.openPosition }
+
+// End returns the offset following the closing parenthesis.
+func (l *GroupingExpression) End() int { return l.closePosition + 1 }
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // UnaryExpression represents a NOT (!) expression.
@@ -62,14 +115,30 @@ func (l *UnaryExpression) Evaluate(data *Data) (interface{}, error) {
 		return false, err
 	}
 
+	if data.environment().options.loose {
+		value = truthy(value)
+	}
+
 	booleanValue, ok := value.(bool)
 	if !ok {
-		return nil, fmt.Errorf("unary expression must be of type boolean (position=%d)", l.position)
+		return nil, locate(newTypeError(l.position, "unary expression must be of type boolean"), l, NOT)
 	}
 
 	return !booleanValue, nil
 }
 
+// Operator returns the NOT token.
+func (l *UnaryExpression) Operator() Token { return NOT }
+
+// Operand returns the negated expression.
+func (l *UnaryExpression) Operand() Node { return l.Node }
+
+// Pos returns the offset of the operator.
+func (l *UnaryExpression) Pos() int { return l.position }
+
+// End returns the offset following the operand.
+func (l *UnaryExpression) End() int { return l.Node.End() }
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // BinaryExpression represents a comparison or logical operation between two nodes.
@@ -80,25 +149,96 @@ type BinaryExpression struct {
 	right    Node
 }
 
-// Evaluate computes the result of the binary operation on the left and right operands.
+// Evaluate computes the result of the binary operation on the left and right operands. The right
+// operand of AND and OR is only evaluated when the left operand doesn't decide the result, so
+// that it can be guarded by the left one, e.g. `reading is number && reading > 20`.
 func (l *BinaryExpression) Evaluate(data *Data) (interface{}, error) {
 
+	options := data.environment().options
+
 	left, err := l.left.Evaluate(data)
 	if err != nil {
 		return nil, err
 	}
+
+	if options.loose && l.token.BooleanOperator() {
+		left = truthy(left)
+	}
+
+	if b, ok := left.(bool); ok && l.token.BooleanOperator() && b == (l.token == OR) {
+		return b, nil
+	}
+
 	right, err := l.right.Evaluate(data)
 	if err != nil {
 		return nil, err
 	}
 
+	if options.loose && l.token.BooleanOperator() {
+		return truthy(right), nil
+	}
+
+	var value interface{}
+
+	switch {
+	case l.token == IN:
+		value, err = member(left, right, l.position, options.comparator())
+	case l.token.BitwiseOperator():
+		value, err = bitwise(left, right, l.token, l.position)
+	case l.token == OF:
+		value, err = percentOf(left, right, l.position)
+	case l.token == APPROX:
+		if options.loose {
+			left, right = coerce(left, right)
+		}
+		value, err = approximate(left, right, options.approximation(), l.position)
+	default:
+		value, err = options.comparator()(left, right, l.token, l.position)
+	}
+
+	return value, locate(err, l, l.token)
+}
+
+// Operator returns the token of the binary operator.
+func (l *BinaryExpression) Operator() Token { return l.token }
+
+// OperatorPos returns the offset of the operator.
+func (l *BinaryExpression) OperatorPos() int { return l.position }
+
+// Left returns the left operand.
+func (l *BinaryExpression) Left() Node { return l.left }
+
+// Right returns the right operand.
+func (l *BinaryExpression) Right() Node { return l.right }
+
+// Pos returns the offset of the left operand.
+func (l *BinaryExpression) Pos() int { return l.left.Pos() }
+
+// End returns the offset following the right operand.
+func (l *BinaryExpression) End() int { return l.right.End() }
+
+// compare applies a comparison or logical operator to two evaluated operands. Null is only equal
+// to null, and can't be ordered.
+func compare(left, right interface{}, token Token, position int) (interface{}, error) {
+
+	if left == nil || right == nil {
+		switch token {
+		case EQUAL:
+			return left == right, nil
+		case NOT_EQUAL:
+			return left != right, nil
+		default:
+			return false, newTypeError(position, "can't compare type '%T' with type '%T'", left, right)
+		}
+	}
+
 	switch lv := left.(type) {
 	case bool:
 		rv, ok := right.(bool)
 		if !ok {
-			return false, fmt.Errorf("can't compare type 'bool' with type '%T' (position=%d)", right, l.position)
+			return false, newTypeError(position, "can't compare type 'bool' with type '%T'", right)
 		}
-		switch l.token {
+		switch token {
 		case EQUAL:
 			return lv == rv, nil
 		case NOT_EQUAL:
@@ -108,50 +248,84 @@ func (l *BinaryExpression) Evaluate(data *Data) (interface{}, error) {
 		case OR:
 			return lv || rv, nil
 		default:
-			return false, fmt.Errorf("type 'bool' only supports the EQUAL, NOT_EQUAL, AND and OR operators (position=%d)", l.position)
+			return false, newTypeError(position, "type 'bool' only supports the EQUAL, NOT_EQUAL, AND and OR operators")
 		}
 
 	case string:
+		switch right.(type) {
+		case netip.Addr:
+			return compareIP(lv, right, token, position)
+		case Version:
+			return compareVersion(lv, right, token, position)
+		case time.Time:
+			return compareTime(lv, right, token, position)
+		}
 		rv, ok := right.(string)
 		if !ok {
-			return false, fmt.Errorf("can't compare type 'string' with type '%T' (position=%d)", right, l.position)
+			return false, newTypeError(position, "can't compare type 'string' with type '%T'", right)
 		}
-		switch l.token {
+		switch token {
 		case EQUAL:
 			return lv == rv, nil
 		case NOT_EQUAL:
 			return lv != rv, nil
+		case LESS:
+			return lv < rv, nil
+		case LESS_OR_EQUAL:
+			return lv <= rv, nil
+		case GREATER:
+			return lv > rv, nil
+		case GREATER_OR_EQUAL:
+			return lv >= rv, nil
 		default:
-			return false, fmt.Errorf("type 'string' only supports the EQUAL and NOT_EQUAL operators (position=%d)", l.position)
+			return false, newTypeError(position, "type 'string' only supports the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
 		}
 
+	case netip.Addr:
+		return compareIP(lv, right, token, position)
+
+	case Version:
+		return compareVersion(lv, right, token, position)
+
+	case time.Time:
+		return compareTime(lv, right, token, position)
+
 	default:
+		if isDecimal(left) || isDecimal(right) {
+			leftRat, leftOk := toRat(left)
+			rightRat, rightOk := toRat(right)
+			if !leftOk || !rightOk {
+				return false, newTypeError(position, "can't compare type '%T' with type '%T'", left, right)
+			}
+			return compareRat(leftRat, rightRat, token, position)
+		}
+
 		leftInt, leftBig, leftFloat, leftKind := toNumeric(left)
 		rightInt, rightBig, rightFloat, rightKind := toNumeric(right)
 
 		if leftKind == numNone || rightKind == numNone {
-			return false, fmt.Errorf("can't compare type '%T' with type '%T' (position=%d)", left, right, l.position)
+			return false, newTypeError(position, "can't compare type '%T' with type '%T'", left, right)
 		}
 
 		if leftKind == numInt64 && rightKind == numInt64 {
-			return compareInt64(leftInt, rightInt, l.token, l.position)
+			return compareInt64(leftInt, rightInt, token, position)
 		}
 
 		if leftKind == numFloat64 && rightKind == numFloat64 {
-			return compareFloat64(leftFloat, rightFloat, l.token, l.position)
+			return compareFloat64(leftFloat, rightFloat, token, position)
 		}
 
 		leftBig = promoteToBI(leftInt, leftBig, leftKind)
 		rightBig = promoteToBI(rightInt, rightBig, rightKind)
 
 		if leftKind != numFloat64 && rightKind != numFloat64 {
-			return compareBigInt(leftBig, rightBig, l.token, l.position)
+			return compareBigInt(leftBig, rightBig, token, position)
 		}
 
 		if leftKind == numFloat64 {
-			return compareFloatBigInt(leftFloat, rightBig, l.token, l.position)
+			return compareFloatBigInt(leftFloat, rightBig, token, position)
 		}
-		return compareBigIntFloat(leftBig, rightFloat, l.token, l.position)
+		return compareBigIntFloat(leftBig, rightFloat, token, position)
 	}
 }
 
@@ -204,6 +378,101 @@ func promoteToBI(i64 int64, bi *big.Int, kind numKind) *big.Int {
 	return bi
 }
 
+// maxValueSize bounds the size of the values an expression computes, so that it can't allocate
+// arbitrarily large values: the shift count of SHIFT_LEFT, the bit length of the integers of the pow
+// function, and the width of the pad_left function.
+const maxValueSize = 1 << 16
+
+// bitwise applies a bitwise operator to two integer operands. Operands are promoted to *big.Int,
+// and negative integers behave as in two's complement representation.
+func bitwise(left, right interface{}, token Token, position int) (interface{}, error) {
+
+	leftInt, leftBig, _, leftKind := toNumeric(left)
+	rightInt, rightBig, _, rightKind := toNumeric(right)
+
+	if (leftKind != numInt64 && leftKind != numBigInt) || (rightKind != numInt64 && rightKind != numBigInt) {
+		return nil, newTypeError(position, "bitwise operator %s requires integer operands, got type '%T' and type '%T'", token, left, right)
+	}
+
+	leftBig = promoteToBI(leftInt, leftBig, leftKind)
+	rightBig = promoteToBI(rightInt, rightBig, rightKind)
+
+	result := new(big.Int)
+
+	switch token {
+	case BIT_AND:
+		return result.And(leftBig, rightBig), nil
+	case BIT_OR:
+		return result.Or(leftBig, rightBig), nil
+	case BIT_XOR:
+		return result.Xor(leftBig, rightBig), nil
+	case BIT_AND_NOT:
+		return result.AndNot(leftBig, rightBig), nil
+	}
+
+	if rightBig.Sign() < 0 || !rightBig.IsInt64() || (token == SHIFT_LEFT && rightBig.Int64() > maxValueSize) {
+		return nil, newTypeError(position, "invalid shift count %s", rightBig)
+	}
+
+	switch token {
+	case SHIFT_LEFT:
+		return result.Lsh(leftBig, uint(rightBig.Int64())), nil
+	case SHIFT_RIGHT:
+		return result.Rsh(leftBig, uint(rightBig.Int64())), nil
+	default:
+		return nil, newTypeError(position, "unknown bitwise operator %s", token)
+	}
+}
+
+func isDecimal(v interface{}) bool {
+	_, ok := v.(*big.Rat)
+	return ok
+}
+
+// toRat converts a numeric value to an exact rational. A float64 is read as the shortest decimal
+// that represents it, e.g. 0.1 and not its binary approximation 0.1000000000000000055511151231257827.
+func toRat(v interface{}) (*big.Rat, bool) {
+
+	if r, ok := v.(*big.Rat); ok {
+		return r, true
+	}
+
+	i64, bi, f64, kind := toNumeric(v)
+
+	switch kind {
+	case numInt64:
+		return new(big.Rat).SetInt64(i64), true
+	case numBigInt:
+		return new(big.Rat).SetInt(bi), true
+	case numFloat64:
+		if math.IsInf(f64, 0) || math.IsNaN(f64) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(strconv.FormatFloat(f64, 'g', -1, 64))
+	default:
+		return nil, false
+	}
+}
+
+func compareRat(l, r *big.Rat, token Token, pos int) (interface{}, error) {
+	switch token {
+	case EQUAL:
+		return l.Cmp(r) == 0, nil
+	case NOT_EQUAL:
+		return l.Cmp(r) != 0, nil
+	case LESS:
+		return l.Cmp(r) < 0, nil
+	case LESS_OR_EQUAL:
+		return l.Cmp(r) <= 0, nil
+	case GREATER:
+		return l.Cmp(r) > 0, nil
+	case GREATER_OR_EQUAL:
+		return l.Cmp(r) >= 0, nil
+	default:
+		return false, newTypeError(pos, "numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
+	}
+}
+
 func compareInt64(l, r int64, token Token, pos int) (interface{}, error) {
 	switch token {
 	case EQUAL:
@@ -219,7 +488,7 @@ func compareInt64(l, r int64, token Token, pos int) (interface{}, error) {
 	case GREATER_OR_EQUAL:
 		return l >= r, nil
 	default:
-		return false, fmt.Errorf("numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators (position=%d)", pos)
+		return false, newTypeError(pos, "numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
 	}
 }
 
@@ -238,7 +507,7 @@ func compareFloat64(l, r float64, token Token, pos int) (interface{}, error) {
 	case GREATER_OR_EQUAL:
 		return l >= r, nil
 	default:
-		return false, fmt.Errorf("numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators (position=%d)", pos)
+		return false, newTypeError(pos, "numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
 	}
 }
 
@@ -257,7 +526,7 @@ func compareBigInt(l, r *big.Int, token Token, pos int) (interface{}, error) {
 	case GREATER_OR_EQUAL:
 		return l.Cmp(r) >= 0, nil
 	default:
-		return false, fmt.Errorf("numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators (position=%d)", pos)
+		return false, newTypeError(pos, "numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
 	}
 }
 
@@ -278,7 +547,7 @@ func compareBigIntFloat(l *big.Int, r float64, token Token, pos int) (interface{
 	case GREATER_OR_EQUAL:
 		return (l.Cmp(rRounded) == 0 && (accuracy == big.Exact || accuracy == big.Above)) || l.Cmp(rRounded) == 1, nil
 	default:
-		return false, fmt.Errorf("numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators (position=%d)", pos)
+		return false, newTypeError(pos, "numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
 	}
 }
 
@@ -299,14 +568,16 @@ func compareFloatBigInt(l float64, r *big.Int, token Token, pos int) (interface{
 	case GREATER_OR_EQUAL:
 		return (lRounded.Cmp(r) == 0 && (accuracy == big.Exact || accuracy == big.Below)) || lRounded.Cmp(r) == 1, nil
 	default:
-		return false, fmt.Errorf("numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators (position=%d)", pos)
+		return false, newTypeError(pos, "numeric types only support the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
 	}
 }
 
 // LiteralInteger represents an arbitrary-precision integer literal.
 type LiteralInteger struct {
 	value    *big.Int
+	raw      string
 	position int
+	end      int
 }
 
 // Evaluate returns the integer value.
@@ -314,10 +585,21 @@ func (l *LiteralInteger) Evaluate(_ *Data) (interface{}, error) {
 	return l.value, nil
 }
 
+// Value returns the integer value. Byte size literals, e.g. 512MB, are numbers of bytes.
+func (l *LiteralInteger) Value() *big.Int { return l.value }
+
+// Pos returns the offset of the literal.
+func (l *LiteralInteger) Pos() int { return l.position }
+
+// End returns the offset following the literal.
+func (l *LiteralInteger) End() int { return l.end }
+
 // LiteralFloat represents a 64-bit floating-point literal.
 type LiteralFloat struct {
 	value    float64
+	raw      string
 	position int
+	end      int
 }
 
 // Evaluate returns the float value.
@@ -325,10 +607,42 @@ func (l *LiteralFloat) Evaluate(_ *Data) (interface{}, error) {
 	return l.value, nil
 }
 
+// Value returns the float value. Percentage literals, e.g. 20%, are fractions.
+func (l *LiteralFloat) Value() float64 { return l.value }
+
+// Pos returns the offset of the literal.
+func (l *LiteralFloat) Pos() int { return l.position }
+
+// End returns the offset following the literal.
+func (l *LiteralFloat) End() int { return l.end }
+
+// LiteralDecimal represents an exact decimal literal, read in decimal mode.
+type LiteralDecimal struct {
+	value    *big.Rat
+	raw      string
+	position int
+	end      int
+}
+
+// Evaluate returns the decimal value.
+func (l *LiteralDecimal) Evaluate(_ *Data) (interface{}, error) {
+	return l.value, nil
+}
+
+// Value returns the exact decimal value.
+func (l *LiteralDecimal) Value() *big.Rat { return l.value }
+
+// Pos returns the offset of the literal.
+func (l *LiteralDecimal) Pos() int { return l.position }
+
+// End returns the offset following the literal.
+func (l *LiteralDecimal) End() int { return l.end }
+
 // LiteralString represents a quoted string literal.
 type LiteralString struct {
 	value    string
 	position int
+	end      int
 }
 
 // Evaluate returns the string value.
@@ -336,14 +650,26 @@ func (l *LiteralString) Evaluate(_ *Data) (interface{}, error) {
 	return l.value, nil
 }
 
+// Value returns the string value, without quotes.
+func (l *LiteralString) Value() string { return l.value }
+
+// Pos returns the offset of the opening quote.
+func (l *LiteralString) Pos() int { return l.position }
+
+// End returns the offset following the closing quote.
+func (l *LiteralString) End() int { return l.end }
+
 // LiteralIdent represents a variable reference or boolean keyword (true/false).
 type LiteralIdent struct {
 	identifier string
 	position   int
+	end        int
+	binding    *Binding
 }
 
-// Evaluate resolves the identifier: "true" and "false" return booleans,
-// anything else is looked up in the data store.
+// Evaluate resolves the identifier: "true" and "false" return booleans, references to a let
+// binding return the value of the binding, anything else is looked up in the data store. An
+// optional identifier of the schema that is missing from the data evaluates to null.
 func (l *LiteralIdent) Evaluate(data *Data) (interface{}, error) {
 
 	if l.identifier == "true" {
@@ -354,5 +680,225 @@ func (l *LiteralIdent) Evaluate(data *Data) (interface{}, error) {
 		return false, nil
 	}
 
-	return data.Find(l.identifier)
+	if l.binding != nil {
+		return data.environment().resolve(l, data)
+	}
+
+	value, err := data.Find(l.identifier)
+	if errors.Is(err, prefixtree.ErrPrefixNotFound) && data.environment().options.schema.optional(l.identifier) {
+		return nil, nil // optional identifiers missing from the data are null
+	}
+	if err != nil {
+		return nil, identifierError(err, l)
+	}
+
+	return value, nil
+}
+
+// Name returns the identifier.
+func (l *LiteralIdent) Name() string { return l.identifier }
+
+// Binding returns the let binding the identifier refers to, or nil if it refers to the data or is a
+// boolean keyword.
+func (l *LiteralIdent) Binding() *Binding { return l.binding }
+
+// Pos returns the offset of the identifier.
+func (l *LiteralIdent) Pos() int { return l.position }
+
+// End returns the offset following the identifier.
+func (l *LiteralIdent) End() int { return l.end }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// LetExpression binds named sub-expressions that its body references by name. A binding is
+// evaluated at most once per evaluation, the first time it is referenced.
+type LetExpression struct {
+	bindings []*Binding
+	Node
+	position int
+}
+
+// Binding is a named sub-expression of a let expression.
+type Binding struct {
+	name     string
+	value    Node
+	position int
+	let      *LetExpression
+	index    int
+}
+
+// Name returns the name of the binding.
+func (b *Binding) Name() string { return b.name }
+
+// Value returns the bound expression.
+func (b *Binding) Value() Node { return b.value }
+
+// Pos returns the offset of the name of the binding.
+func (b *Binding) Pos() int { return b.position }
+
+// End returns the offset following the bound expression.
+func (b *Binding) End() int { return b.value.End() }
+
+// Evaluate evaluates the body of the let expression in a new binding frame. A warning is raised
+// for every binding whose name shadows an identifier of the data.
+func (l *LetExpression) Evaluate(data *Data) (interface{}, error) {
+
+	env := data.environment()
+
+	for _, b := range l.bindings {
+		if _, err := data.Find(b.name); err == nil {
+			env.options.warn(b.position, "let binding '%s' shadows an identifier of the data", b.name)
+		}
+	}
+
+	scoped := *env
+	scoped.frame = &frame{
+		parent:  env.frame,
+		let:     l,
+		results: make([]*bindingResult, len(l.bindings)),
+	}
+
+	return l.Node.Evaluate(data.with(&scoped))
+}
+
+// Bindings returns the bindings of the let expression, in declaration order.
+func (l *LetExpression) Bindings() []*Binding { return l.bindings }
+
+// Body returns the expression the bindings are referenced from.
+func (l *LetExpression) Body() Node { return l.Node }
+
+// Pos returns the offset of the LET keyword.
+func (l *LetExpression) Pos() int { return l.position }
+
+// End returns the offset following the body.
+func (l *LetExpression) End() int { return l.Node.End() }
+
+// frame memoizes the bindings of a let expression during a single evaluation.
+type frame struct {
+	parent  *frame
+	let     *LetExpression
+	results []*bindingResult
+}
+
+type bindingResult struct {
+	value      interface{}
+	err        error
+	evaluating bool
+}
+
+func (e *environment) resolve(l *LiteralIdent, data *Data) (interface{}, error) {
+
+	b := l.binding
+
+	for f := e.frame; f != nil; f = f.parent {
+
+		if f.let != b.let {
+			continue
+		}
+
+		if result := f.results[b.index]; result != nil {
+			if result.evaluating {
+				return nil, &SyntaxError{
+					Msg:   fmt.Sprintf("let binding '%s' references itself", b.name),
+					Pos:   l.position,
+					End:   l.end,
+					Token: IDENT,
+				}
+			}
+			return result.value, result.err
+		}
+
+		result := &bindingResult{evaluating: true}
+		f.results[b.index] = result
+		scoped := *e
+		scoped.frame = f
+		result.value, result.err = b.value.Evaluate(data.with(&scoped))
+		result.evaluating = false
+
+		return result.value, result.err
+	}
+
+	return nil, &UnknownIdentifierError{Name: l.identifier, Pos: l.position, End: l.end} // outside of its let expression
+}
+
+// member reports whether the item belongs to the list, the range or the network on the right side
+// of the IN operator. An IP address, possibly in its string form, belongs to a list if one of its
+// elements is a network containing it.
+func member(item, collection interface{}, position int, compare comparator) (interface{}, error) {
+
+	switch c := collection.(type) {
+	case []interface{}:
+		for _, element := range c {
+			var found interface{}
+			var err error
+			if addr, ok := item.(netip.Addr); ok {
+				found, err = networkContains(element, addr, position)
+			} else if addr, network, ok := addressInNetwork(item, element); ok {
+				found = network.Contains(addr)
+			} else {
+				found, err = compare(item, element, EQUAL, position)
+			}
+			if err != nil {
+				return false, err
+			}
+			if found.(bool) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case valueRange:
+		return inRange(item, c, position, compare)
+
+	case netip.Prefix, netip.Addr:
+		switch i := item.(type) {
+		case netip.Addr:
+			return networkContains(c, i, position)
+		case string:
+			addr, err := parseIP(i)
+			if err != nil {
+				return false, newTypeError(position, "can't look for malformed IP address %q in a network", i)
+			}
+			return networkContains(c, addr, position)
+		default:
+			return false, newTypeError(position, "can't look for type '%T' in a network", item)
+		}
+
+	default:
+		return false, newTypeError(position, "operator IN expects a list, a range or a network on its right side, got type '%T'", collection)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ListExpression represents a list literal enclosed in square brackets.
+type ListExpression struct {
+	openPosition  int
+	elements      []Node
+	closePosition int
 }
+
+// Evaluate returns the list of the evaluated elements.
+func (l *ListExpression) Evaluate(data *Data) (interface{}, error) {
+
+	list := make([]interface{}, 0, len(l.elements))
+
+	for _, element := range l.elements {
+		value, err := element.Evaluate(data)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+
+	return list, nil
+}
+
+// Elements returns the elements of the list.
+func (l *ListExpression) Elements() []Node { return l.elements }
+
+// Pos returns the offset of the opening bracket.
+func (l *ListExpression) Pos() int { return l.openPosition }
+
+// End returns the offset following the closing bracket.
+func (l *ListExpression) End() int { return l.closePosition + 1 }
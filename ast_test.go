@@ -920,3 +920,106 @@ func TestBinaryExpression_Evaluate(t *testing.T) {
 		})
 	})
 }
+
+func TestDecimal(t *testing.T) {
+
+	evaluate := func(t *testing.T, expression string, price interface{}, opts ...Option) bool {
+		evaluate, err := NewExpression(expression, opts...)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("price", price))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		return result
+	}
+
+	t.Run("decimal literals are exact in decimal mode", func(t *testing.T) {
+		assert.True(t, evaluate(t, `price == 0.3`, big.NewRat(3, 10), WithDecimal()))
+		assert.True(t, evaluate(t, `price < 19.99`, big.NewRat(1998, 100), WithDecimal()))
+		assert.False(t, evaluate(t, `price > 0.30000000000000001`, big.NewRat(3, 10), WithDecimal()))
+	})
+
+	t.Run("float literals are binary outside decimal mode", func(t *testing.T) {
+		assert.True(t, evaluate(t, `price == 0.30000000000000001`, 0.3))
+	})
+
+	t.Run("float data compares with decimals through its shortest decimal form", func(t *testing.T) {
+		a, b := 0.1, 0.2
+		assert.True(t, evaluate(t, `price == 0.1`, a, WithDecimal()))
+		assert.False(t, evaluate(t, `price == 0.3`, a+b, WithDecimal()))
+		assert.True(t, evaluate(t, `price > 0.3`, a+b, WithDecimal()))
+	})
+
+	t.Run("big.Float data is stored as an exact decimal", func(t *testing.T) {
+		price, _ := new(big.Float).SetPrec(200).SetString("12.5")
+		assert.True(t, evaluate(t, `price == 12.5`, price, WithDecimal()))
+		assert.True(t, evaluate(t, `price == 12.5`, price))
+	})
+
+	t.Run("big.Float data is read as its shortest decimal, like float64 data", func(t *testing.T) {
+		a, b := 0.1, 0.2
+		assert.True(t, evaluate(t, `price == 0.1`, big.NewFloat(a), WithDecimal()))
+		assert.False(t, evaluate(t, `price == 0.3`, big.NewFloat(a+b), WithDecimal()))
+	})
+
+	t.Run("decimals compare with integers", func(t *testing.T) {
+		assert.True(t, evaluate(t, `price >= 12 && price < 13`, big.NewRat(25, 2)))
+		assert.True(t, evaluate(t, `price == 100000000000000000000000`, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(23), nil))))
+	})
+
+	t.Run("decimals don't compare with strings", func(t *testing.T) {
+		evaluate, err := NewExpression(`price == 'cheap'`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("price", big.NewRat(1, 2)))
+
+		_, err = evaluate(data)
+		assert.Error(t, err)
+	})
+}
+
+func TestBitwise(t *testing.T) {
+
+	evaluate := func(t *testing.T, token Token, left, right interface{}) interface{} {
+		result, err := bitwise(left, right, token, 0)
+		assert.NoError(t, err)
+		return result
+	}
+
+	t.Run("operators", func(t *testing.T) {
+		assert.Equal(t, big.NewInt(0b0100), evaluate(t, BIT_AND, 0b0110, big.NewInt(0b1100)))
+		assert.Equal(t, big.NewInt(0b1110), evaluate(t, BIT_OR, int64(0b0110), 0b1100))
+		assert.Equal(t, big.NewInt(0b1010), evaluate(t, BIT_XOR, uint8(0b0110), 0b1100))
+		assert.Equal(t, big.NewInt(0b0010), evaluate(t, BIT_AND_NOT, 0b0110, uint64(0b1100)))
+		assert.Equal(t, big.NewInt(0b11000), evaluate(t, SHIFT_LEFT, 0b0110, 2))
+		assert.Equal(t, big.NewInt(0b0001), evaluate(t, SHIFT_RIGHT, 0b0110, 2))
+	})
+
+	t.Run("beyond 64 bits", func(t *testing.T) {
+		expected, _ := new(big.Int).SetString("18446744073709551616", 10)
+		assert.Equal(t, expected, evaluate(t, SHIFT_LEFT, 1, 64))
+		assert.Equal(t, big.NewInt(1), evaluate(t, SHIFT_RIGHT, expected, 64))
+		assert.Equal(t, 0, evaluate(t, SHIFT_RIGHT, expected, int64(1)<<62).(*big.Int).Sign())
+	})
+
+	t.Run("negative integers use two's complement", func(t *testing.T) {
+		assert.Equal(t, big.NewInt(-8), evaluate(t, BIT_AND, -6, -4))
+		assert.Equal(t, big.NewInt(-2), evaluate(t, SHIFT_RIGHT, -3, 1))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, operands := range [][3]interface{}{
+			{BIT_AND, 1.5, 1},
+			{BIT_OR, 1, "1"},
+			{BIT_XOR, true, 1},
+			{SHIFT_LEFT, 1, -1},
+			{SHIFT_LEFT, 1, maxValueSize + 1},
+		} {
+			_, err := bitwise(operands[1], operands[2], operands[0].(Token), 0)
+			assert.Error(t, err, operands)
+		}
+	})
+}
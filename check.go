@@ -0,0 +1,379 @@
+package boule
+
+import "fmt"
+
+// checker infers the types of the nodes of an expression, and the types of its placeholders from
+// their uses. With a schema, it also reports the identifiers missing from the schema and the
+// operands of the wrong type.
+type checker struct {
+	ast      *AST
+	schema   *Schema
+	strict   bool
+	bindings map[*Binding]Type
+}
+
+// check infers the types of the placeholders of the expression, and type-checks the expression
+// against the schema, if any, verifying that it is of type bool. In loose mode, where operands are
+// coerced, only the identifiers are checked. Errors are reported to the parser, so that they are
+// returned with the syntax errors of the expression.
+func (a *AST) check(schema *Schema) {
+
+	c := &checker{
+		ast:      a,
+		schema:   schema,
+		strict:   schema != nil && !a.options.loose,
+		bindings: make(map[*Binding]Type),
+	}
+
+	if t := c.typeOf(a.program); !c.infer(a.program, TypeBool) && c.strict && t != TypeAny && t != TypeBool {
+		a.report(&TypeError{
+			Msg: fmt.Sprintf("expression must be of type 'bool', got type '%s'", t),
+			Pos: a.program.Pos(),
+			End: a.program.End(),
+		})
+	}
+}
+
+// typeOf returns the type the node evaluates to, TypeAny if it can't be known before evaluation,
+// and checks the operands of the node and of its children.
+func (c *checker) typeOf(node Node) Type {
+	switch n := node.(type) {
+	case *GroupingExpression:
+		return c.typeOf(n.Node)
+	case *LiteralInteger, *LiteralFloat, *LiteralDecimal:
+		return TypeNumber
+	case *LiteralString:
+		return TypeString
+	case *LiteralIP:
+		return TypeIP
+	case *LiteralCIDR:
+		return TypeNetwork
+	case *LiteralIdent:
+		return c.identifier(n)
+	case *Parameter:
+		if inferred := c.ast.parameters[n.name]; inferred != nil {
+			return inferred.Type
+		}
+	case *ListExpression:
+		for _, element := range n.elements {
+			c.typeOf(element)
+		}
+		return TypeList
+	case *CallExpression:
+		for _, argument := range n.arguments {
+			c.typeOf(argument)
+		}
+		if n.function != nil { // nil for unknown functions, reported as syntax errors
+			return n.function.returns
+		}
+	case *LetExpression:
+		for _, b := range n.bindings {
+			c.binding(b)
+		}
+		return c.typeOf(n.Node)
+	case *UnaryExpression:
+		c.expect(n, NOT, n.Node, c.typeOf(n.Node), TypeBool)
+		return TypeBool
+	case *BinaryExpression:
+		return c.binary(n)
+	case *BetweenExpression:
+		c.ordered(n, BETWEEN, n.value, n.low, n.high)
+		return TypeBool
+	case *RangeExpression:
+		token := RANGE
+		if n.exclusive {
+			token = RANGE_EXCLUSIVE
+		}
+		c.ordered(n, token, n.low, n.high)
+	case *MatchExpression:
+		c.expect(n, n.token, n.value, c.typeOf(n.value), TypeString)
+		c.expect(n, n.token, n.pattern, c.typeOf(n.pattern), TypeString)
+		return TypeBool
+	case *TypeTestExpression:
+		c.typeOf(n.value)
+		return TypeBool
+	}
+	return TypeAny
+}
+
+// identifier returns the type of a boolean keyword, of a let binding, or of an identifier of the
+// schema, and reports identifiers that aren't declared in the schema.
+func (c *checker) identifier(l *LiteralIdent) Type {
+
+	switch {
+	case l.identifier == "true" || l.identifier == "false":
+		return TypeBool
+	case l.binding != nil:
+		return c.binding(l.binding)
+	case c.schema == nil:
+		return TypeAny
+	}
+
+	field, err := c.schema.lookup(l.identifier)
+	if err != nil {
+		c.ast.report(identifierError(err, l))
+		return TypeAny
+	}
+
+	return field.Type
+}
+
+// binding returns the type of a let binding, checking its value the first time it is referenced.
+func (c *checker) binding(b *Binding) Type {
+
+	if t, ok := c.bindings[b]; ok {
+		return t
+	}
+
+	c.bindings[b] = TypeAny // guards against cycles, reported as syntax errors
+	c.bindings[b] = c.typeOf(b.value)
+
+	return c.bindings[b]
+}
+
+// binary checks the operands of a binary expression, and returns its type.
+func (c *checker) binary(b *BinaryExpression) Type {
+
+	switch {
+	case b.token == IN:
+		c.in(b)
+		return TypeBool
+	case b.token.BooleanOperator():
+		c.operands(b, TypeBool)
+		return TypeBool
+	case b.token.BitwiseOperator() || b.token == OF:
+		c.operands(b, TypeNumber)
+		return TypeNumber
+	case b.token == APPROX:
+		c.operands(b, TypeNumber)
+		return TypeBool
+	case b.token == EQUAL || b.token == NOT_EQUAL:
+		c.equality(b)
+		return TypeBool
+	}
+
+	c.ordered(b, b.token, b.left, b.right)
+	return TypeBool
+}
+
+// operands checks that both operands of a binary expression are of the expected type.
+func (c *checker) operands(b *BinaryExpression, expected Type) {
+	left, right := c.typeOf(b.left), c.typeOf(b.right)
+	c.expect(b, b.token, b.left, left, expected)
+	c.expect(b, b.token, b.right, right, expected)
+}
+
+// equality checks that the operands of an equality can be compared with each other, a placeholder
+// operand being expected to be of the type of the other operand.
+func (c *checker) equality(b *BinaryExpression) {
+
+	left, right := c.typeOf(b.left), c.typeOf(b.right)
+
+	parameters := c.infer(b.left, right)
+	parameters = c.infer(b.right, left) || parameters
+
+	if c.strict && !parameters && !compatible(left, right, b.token) {
+		c.mismatch(b, b.token, "operator '%s' can't compare type '%s' with type '%s'", b.token, left, right)
+	} else {
+		c.enumerated(b, b.token, b.left, b.right)
+		c.enumerated(b, b.token, b.right, b.left)
+	}
+}
+
+// in checks the operands of the IN operator, whose right operand is a list, a range or a network.
+func (c *checker) in(b *BinaryExpression) {
+
+	left := c.typeOf(b.left)
+
+	right := ungroup(b.right)
+
+	if r, ok := right.(*RangeExpression); ok {
+		c.ordered(b, IN, b.left, r.low, r.high)
+		return
+	}
+
+	if list, ok := right.(*ListExpression); ok {
+		for _, element := range list.elements {
+			c.enumerated(b, IN, b.left, element)
+		}
+	}
+
+	switch t := c.typeOf(right); {
+	case !c.strict || t == TypeAny:
+	case t == TypeList:
+		list, ok := right.(*ListExpression)
+		if !ok || left == TypeIP { // addresses are looked up in the networks of lists
+			return
+		}
+		for _, element := range list.elements {
+			if e := c.typeOf(element); !compatible(left, e, EQUAL) {
+				c.mismatch(b, IN, "operator '%s' can't look for type '%s' among elements of type '%s'", IN, left, e)
+				return
+			}
+		}
+	case t == TypeNetwork || t == TypeIP:
+		if left != TypeAny && left != TypeIP { // placeholders may be addresses in their string form
+			c.mismatch(b, IN, "operator '%s' expects type '%s', got type '%s'", IN, TypeIP, left)
+		}
+	default:
+		c.mismatch(b, IN, "operator '%s' expects a list, a range or a network on its right side, got type '%s'", IN, t)
+	}
+}
+
+// ordered checks that the operands compared by order, by BETWEEN or by a range, are of types that
+// can be compared with each other. Placeholder operands are expected to be of the first ordered
+// type known among the operands.
+func (c *checker) ordered(node Node, token Token, operands ...Node) {
+
+	types := make([]Type, 0, len(operands))
+	expected := typeOrdered
+	for _, operand := range operands {
+		t := c.typeOf(operand)
+		if expected == typeOrdered && t.ordered() {
+			expected = t
+		}
+		types = append(types, t)
+	}
+
+	var parameters []bool
+	for _, operand := range operands {
+		parameters = append(parameters, c.infer(operand, expected))
+	}
+
+	if !c.strict {
+		return
+	}
+
+	for i, left := range types {
+		for j, right := range types[i+1:] {
+			if parameters[i] || parameters[i+1+j] {
+				continue // checked by inference
+			}
+			if !compatible(left, right, LESS) {
+				c.mismatch(node, token, "operator '%s' can't compare type '%s' with type '%s'", token, left, right)
+				return
+			}
+		}
+	}
+}
+
+// enumerated reports a constant compared with an identifier restricted to enumerated values, that
+// is none of them.
+func (c *checker) enumerated(node Node, token Token, identifier, constant Node) {
+
+	field, ok := c.field(identifier)
+	if !ok || !c.strict || len(field.Enum) == 0 || !isConstant(constant) {
+		return
+	}
+
+	value, err := constant.Evaluate(nil)
+	if err != nil {
+		return
+	}
+
+	for _, allowed := range field.Enum {
+		if equal, err := c.ast.options.comparator()(value, allowed, EQUAL, 0); err == nil && equal == true {
+			return
+		}
+	}
+
+	c.mismatch(node, token, "value %s is not one of the values of identifier '%s'", Format(constant), field.Name)
+}
+
+// field returns the identifier of the schema an operand refers to.
+func (c *checker) field(node Node) (Field, bool) {
+
+	l, ok := ungroup(node).(*LiteralIdent)
+	if !ok || l.binding != nil || c.schema == nil {
+		return Field{}, false
+	}
+
+	field, err := c.schema.lookup(l.identifier)
+	return field, err == nil
+}
+
+// expect reports an operand of the operator that isn't of the expected type, a placeholder operand
+// being expected to be of the type.
+func (c *checker) expect(node Node, token Token, operand Node, actual, expected Type) {
+	if !c.infer(operand, expected) && c.strict && actual != TypeAny && actual != expected {
+		c.mismatch(node, token, "operator '%s' expects type '%s', got type '%s'", token, expected, actual)
+	}
+}
+
+// infer records the type an operand is expected to have if it is a placeholder, and reports
+// whether it is one. The first use that determines the type of a placeholder fixes it, and every
+// later use must agree with it.
+func (c *checker) infer(operand Node, expected Type) bool {
+
+	parameter, ok := ungroup(operand).(*Parameter)
+	if !ok {
+		return false
+	}
+
+	inferred := c.ast.parameters[parameter.name]
+
+	switch {
+	case expected == TypeAny:
+	case inferred.Type == TypeAny:
+		inferred.Type = expected
+		inferred.position = parameter.position
+	case inferred.Type == typeOrdered && expected.ordered():
+		inferred.Type = expected // refine to the concrete ordered type
+	case expected == typeOrdered && inferred.Type.ordered():
+	case inferred.Type != expected:
+		c.ast.report(&TypeError{
+			Msg: fmt.Sprintf("parameter '%s' used as type '%s' but its first use at position %d is of type '%s'",
+				parameter.name, expected, inferred.position, inferred.Type),
+			Pos: parameter.position,
+			End: parameter.end,
+		})
+	}
+
+	return true
+}
+
+// mismatch reports a type error spanning the node.
+func (c *checker) mismatch(node Node, token Token, format string, args ...interface{}) {
+	c.ast.report(&TypeError{Msg: fmt.Sprintf(format, args...), Pos: node.Pos(), End: node.End(), Token: token})
+}
+
+// ungroup returns the expression enclosed in parentheses, if any.
+func ungroup(node Node) Node {
+	for {
+		grouping, ok := node.(*GroupingExpression)
+		if !ok {
+			return node
+		}
+		node = grouping.Node
+	}
+}
+
+// compatible reports whether values of the two types can be compared with the operator. Strings
+// compare with IP addresses, versions and times, which they are parsed as.
+func compatible(left, right Type, token Token) bool {
+
+	if left == TypeAny || right == TypeAny {
+		return true
+	}
+
+	if left == TypeString {
+		left, right = right, left
+	}
+
+	equality := token == EQUAL || token == NOT_EQUAL
+
+	switch left {
+	case TypeBool:
+		return right == TypeBool && equality
+	case TypeIP:
+		return (right == TypeIP || right == TypeString) && equality
+	case TypeVersion, TypeTime:
+		return right == left || right == TypeString || right == typeOrdered
+	case TypeNumber, TypeString:
+		return right == left || right == typeOrdered
+	case typeOrdered:
+		return right.ordered()
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+
+	schema := NewSchema()
+	for name, typ := range map[string]Type{
+		"destination": TypeString,
+		"speed":       TypeNumber,
+		"active":      TypeBool,
+		"release":     TypeVersion,
+		"launch":      TypeTime,
+		"address":     TypeIP,
+		"network":     TypeNetwork,
+		"tags":        TypeList,
+		"payload":     TypeAny,
+	} {
+		assert.NoError(t, schema.Add(name, typ))
+	}
+
+	t.Run("accepts well-typed expressions", func(t *testing.T) {
+		for _, expression := range []string{
+			`destination == 'Mars' && speed > 3`,
+			`active`,
+			`!active || speed between 1 and 10`,
+			`release >= semver('1.2.0') && release < '2.0.0'`,
+			`launch > '2024-01-01T00:00:00Z'`,
+			`address == '10.0.0.1' && address in cidr'10.0.0.0/8'`,
+			`address in network || address in [cidr'10.0.0.0/8', ip'192.168.0.1']`,
+			`destination in ['Mars', 'Venus'] && 'red' in tags`,
+			`speed in 1..<10 && (speed & 1) == 0 && 10% of speed < 2`,
+			`speed ~~ 3.5 && destination like 'M%' && upper(destination) glob 'M*'`,
+			`payload > 3 && payload == 'x' && payload is string`,
+			`let fast = speed > 10 in fast && active`,
+			`speed > :limit && destination == $1`,
+		} {
+			_, err := Compile(expression, WithSchema(schema))
+			assert.NoError(t, err, expression)
+		}
+	})
+
+	t.Run("rejects ill-typed expressions before evaluation", func(t *testing.T) {
+		for _, test := range []struct {
+			expression string
+			span       string
+			token      Token
+		}{
+			{expression: `destination > 3`, span: `destination > 3`, token: GREATER},
+			{expression: `active > false`, span: `active > false`, token: GREATER},
+			{expression: `address < '10.0.0.1'`, span: `address < '10.0.0.1'`, token: LESS},
+			{expression: `speed == 3 && destination`, span: `speed == 3 && destination`, token: AND},
+			{expression: `!speed`, span: `!speed`, token: NOT},
+			{expression: `speed between 1 and 'ten'`, span: `speed between 1 and 'ten'`, token: BETWEEN},
+			{expression: `destination in 1..10`, span: `destination in 1..10`, token: IN},
+			{expression: `speed in cidr'10.0.0.0/8'`, span: `speed in cidr'10.0.0.0/8'`, token: IN},
+			{expression: `speed in ['a', 'b']`, span: `speed in ['a', 'b']`, token: IN},
+			{expression: `speed in destination`, span: `speed in destination`, token: IN},
+			{expression: `speed like 'a%'`, span: `speed like 'a%'`, token: LIKE},
+			{expression: `(destination & 1) == 0`, span: `destination & 1`, token: BIT_AND},
+			{expression: `destination ~~ 3`, span: `destination ~~ 3`, token: APPROX},
+			{expression: `let d = destination in d > 3`, span: `d > 3`, token: GREATER},
+			{expression: `speed`, span: `speed`, token: EOF},
+			{expression: `upper(destination)`, span: `upper(destination)`, token: EOF},
+		} {
+			_, err := Compile(test.expression, WithSchema(schema))
+
+			var typeError *TypeError
+			if assert.True(t, errors.As(err, &typeError), "%s: %v", test.expression, err) {
+				assert.Equal(t, test.span, test.expression[typeError.Pos:typeError.End], test.expression)
+				assert.Equal(t, test.token, typeError.Token, test.expression)
+			}
+		}
+	})
+
+	t.Run("rejects undeclared identifiers", func(t *testing.T) {
+		_, err := Compile(`unknown_field == 1`, WithSchema(schema))
+
+		var unknown *UnknownIdentifierError
+		if assert.True(t, errors.As(err, &unknown), err) {
+			assert.Equal(t, "unknown_field", unknown.Name)
+			assert.Equal(t, 0, unknown.Pos)
+			assert.Equal(t, 13, unknown.End)
+		}
+	})
+
+	t.Run("reports all errors at once", func(t *testing.T) {
+		_, err := Compile(`destination > 3 || unknown_field == 1 || speed like 'a%'`, WithSchema(schema))
+
+		var list ErrorList
+		if assert.True(t, errors.As(err, &list), err) {
+			assert.Len(t, list, 3)
+		}
+	})
+
+	t.Run("only checks identifiers in loose mode", func(t *testing.T) {
+		_, err := Compile(`destination > 3 && speed`, WithSchema(schema), WithLooseTypes())
+		assert.NoError(t, err)
+
+		_, err = Compile(`unknown_field == 1`, WithSchema(schema), WithLooseTypes())
+		var unknown *UnknownIdentifierError
+		assert.True(t, errors.As(err, &unknown), err)
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/victordeleau/boule"
+)
+
+// format implements `boule fmt [-l] [-w] [file ...]`, which formats the expression held by each
+// file, or read from stdin when no file is given, and returns the exit code.
+func format(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: boule fmt [-l] [-w] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "boule fmt: can't use -w on stdin")
+			return 2
+		}
+		source, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "boule fmt: %v\n", err)
+			return 1
+		}
+		formatted, err := formatSource(string(source))
+		if err != nil {
+			fmt.Fprintf(stderr, "boule fmt: <stdin>:\n%s", boule.RenderError(string(source), err))
+			return 1
+		}
+		if *list {
+			if formatted != string(source) {
+				fmt.Fprintln(stdout, "<stdin>")
+			}
+			return 0
+		}
+		fmt.Fprint(stdout, formatted)
+		return 0
+	}
+
+	code := 0
+	for _, name := range flags.Args() {
+
+		source, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "boule fmt: %v\n", err)
+			code = 1
+			continue
+		}
+
+		formatted, err := formatSource(string(source))
+		if err != nil {
+			fmt.Fprintf(stderr, "boule fmt: %s:\n%s", name, boule.RenderError(string(source), err))
+			code = 1
+			continue
+		}
+
+		if *list && formatted != string(source) {
+			fmt.Fprintln(stdout, name)
+		}
+
+		if *write {
+			if formatted != string(source) {
+				if err = os.WriteFile(name, []byte(formatted), 0o644); err != nil {
+					fmt.Fprintf(stderr, "boule fmt: %v\n", err)
+					code = 1
+				}
+			}
+			continue
+		}
+
+		if !*list {
+			fmt.Fprint(stdout, formatted)
+		}
+	}
+
+	return code
+}
+
+// formatSource formats an expression, followed by a newline.
+func formatSource(source string) (string, error) {
+
+	ast, err := boule.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	return boule.Format(ast.Root()) + "\n", nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+
+	run := func(stdin string, args ...string) (code int, stdout, stderr string) {
+		var out, err bytes.Buffer
+		code = format(args, strings.NewReader(stdin), &out, &err)
+		return code, out.String(), err.String()
+	}
+
+	file := func(t *testing.T, name, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("formats stdin", func(t *testing.T) {
+		for _, test := range []struct {
+			source   string
+			expected string
+		}{
+			{source: "speed>10&&destination=='Titan'", expected: "speed > 10 && destination == 'Titan'\n"},
+			{source: "speed > 10\n", expected: "speed > 10\n"},
+		} {
+			code, stdout, stderr := run(test.source)
+			assert.Equal(t, 0, code, test.source)
+			assert.Equal(t, test.expected, stdout, test.source)
+			assert.Empty(t, stderr, test.source)
+		}
+	})
+
+	t.Run("lists stdin if its formatting differs", func(t *testing.T) {
+		code, stdout, _ := run("speed>10", "-l")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "<stdin>\n", stdout)
+
+		code, stdout, _ = run("speed > 10\n", "-l")
+		assert.Equal(t, 0, code)
+		assert.Empty(t, stdout)
+	})
+
+	t.Run("formats files", func(t *testing.T) {
+		path := file(t, "filter.boule", "speed>10")
+
+		code, stdout, _ := run("", path)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "speed > 10\n", stdout)
+	})
+
+	t.Run("lists files whose formatting differs", func(t *testing.T) {
+		unformatted := file(t, "unformatted.boule", "speed>10")
+		formatted := file(t, "formatted.boule", "speed > 10\n")
+
+		code, stdout, _ := run("", "-l", unformatted, formatted)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, unformatted+"\n", stdout)
+	})
+
+	t.Run("writes files", func(t *testing.T) {
+		path := file(t, "filter.boule", "speed>10")
+
+		code, stdout, _ := run("", "-w", path)
+		assert.Equal(t, 0, code)
+		assert.Empty(t, stdout)
+
+		content, err := os.ReadFile(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "speed > 10\n", string(content))
+		}
+
+		code, stdout, _ = run("", "-l", "-w", path)
+		assert.Equal(t, 0, code)
+		assert.Empty(t, stdout)
+	})
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		code, stdout, stderr := run("speed > )")
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, "boule fmt: <stdin>:\n")
+		assert.Contains(t, stderr, "error: invalid syntax: unexpected ')'")
+
+		invalid := file(t, "invalid.boule", "speed > )")
+		valid := file(t, "valid.boule", "speed>10")
+
+		code, stdout, stderr = run("", "-w", invalid, valid)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "boule fmt: "+invalid+":\n")
+
+		content, err := os.ReadFile(invalid)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "speed > )", string(content), "invalid files are left untouched")
+		}
+		content, err = os.ReadFile(valid)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "speed > 10\n", string(content), "valid files are still written")
+		}
+	})
+
+	t.Run("rejects invalid usage", func(t *testing.T) {
+		code, _, stderr := run("speed > 10", "-w")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "can't use -w on stdin")
+
+		code, _, stderr = run("", "-x")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "usage: boule fmt")
+
+		code, _, stderr = run("", filepath.Join(t.TempDir(), "missing.boule"))
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "boule fmt: ")
+	})
+}
@@ -2,11 +2,22 @@ package main
 
 import (
 	"fmt"
+	"os"
+
 	"github.com/victordeleau/boule"
 )
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(format(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	example()
+}
+
+func example() {
+
 	// First create a `boule` expression by passing the expression string.
 	// The expression syntax will be checked against the authorized grammar.
 	expressionString := "!arrived && (origin == 'Mars' || (destination == 'Titan')) && !cancelled"
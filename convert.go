@@ -0,0 +1,187 @@
+package boule
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// callInt converts a value to an integer. Integers are returned unchanged, floats and decimals are
+// truncated toward zero, strings are parsed as decimal integers (e.g. "42", "-7", "007" or "1_000"),
+// unless explicitly prefixed with 0x, 0b or 0o (e.g. "0xFF"), and booleans convert to 1 or 0.
+func callInt(arguments []interface{}) (interface{}, error) {
+
+	switch a := arguments[0].(type) {
+	case *big.Rat:
+		return new(big.Int).Quo(a.Num(), a.Denom()), nil
+
+	case string:
+		i, ok := new(big.Int).SetString(integerLiteral(strings.TrimSpace(a)), 0)
+		if !ok {
+			return nil, fmt.Errorf("can't convert %q to an integer", a)
+		}
+		return i, nil
+
+	case bool:
+		if a {
+			return big.NewInt(1), nil
+		}
+		return big.NewInt(0), nil
+	}
+
+	_, _, f64, kind := toNumeric(arguments[0])
+	switch kind {
+	case numInt64, numBigInt:
+		return arguments[0], nil
+	case numFloat64:
+		if math.IsNaN(f64) || math.IsInf(f64, 0) {
+			return nil, fmt.Errorf("can't convert %v to an integer", f64)
+		}
+		i, _ := big.NewFloat(math.Trunc(f64)).Int(nil)
+		return i, nil
+	default:
+		return nil, fmt.Errorf("can't convert type '%T' to an integer", arguments[0])
+	}
+}
+
+// integerLiteral strips the leading zeros of a decimal integer, which a base prefix would otherwise
+// read as octal, e.g. "010" as 8. It is shared by int() and the lexer.
+func integerLiteral(s string) string {
+
+	sign, digits := "", s
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, digits = s[:1], s[1:]
+	}
+
+	if len(digits) > 1 && digits[0] == '0' && strings.IndexByte("xXbBoO", digits[1]) >= 0 {
+		return s
+	}
+
+	if !strings.HasPrefix(digits, "0") {
+		return s
+	}
+
+	trimmed := strings.TrimLeft(digits, "0_")
+	if trimmed == "" || strings.HasSuffix(digits, "_") {
+		return s // zero, or a malformed literal left to SetString
+	}
+
+	return sign + trimmed
+}
+
+// callFloat converts a value to a float64. Numbers are rounded to the nearest float64, strings are
+// parsed as decimal numbers, and booleans convert to 1 or 0.
+func callFloat(arguments []interface{}) (interface{}, error) {
+
+	switch a := arguments[0].(type) {
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		if err != nil {
+			return nil, fmt.Errorf("can't convert %q to a float", a)
+		}
+		return f, nil
+
+	case bool:
+		if a {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	}
+
+	if _, err := numberArgument(arguments, 0); err != nil {
+		return nil, fmt.Errorf("can't convert type '%T' to a float", arguments[0])
+	}
+	return toFloat(arguments[0]), nil
+}
+
+// callString converts a value to its string form. Floats use the shortest representation that reads
+// back to the same value, decimals are written exactly when they have a finite decimal expansion
+// and as a fraction otherwise, and times use the RFC 3339 format.
+func callString(arguments []interface{}) (interface{}, error) {
+
+	switch a := arguments[0].(type) {
+	case string:
+		return a, nil
+	case bool:
+		return strconv.FormatBool(a), nil
+	case *big.Rat:
+		return formatRat(a), nil
+	case time.Time:
+		return a.Format(time.RFC3339Nano), nil
+	case Version, netip.Addr, netip.Prefix:
+		return fmt.Sprint(a), nil
+	}
+
+	i64, bi, f64, kind := toNumeric(arguments[0])
+	switch kind {
+	case numInt64:
+		return strconv.FormatInt(i64, 10), nil
+	case numBigInt:
+		return bi.String(), nil
+	case numFloat64:
+		return strconv.FormatFloat(f64, 'g', -1, 64), nil
+	default:
+		return nil, fmt.Errorf("can't convert type '%T' to a string", arguments[0])
+	}
+}
+
+// formatRat writes a rational in decimal notation if its decimal expansion is finite, i.e. if its
+// denominator has no prime factor other than 2 and 5, and as a fraction otherwise.
+func formatRat(r *big.Rat) string {
+
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	denom := new(big.Int).Set(r.Denom())
+	digits := 0
+	for _, factor := range []int64{2, 5} {
+		f, count := big.NewInt(factor), 0
+		for new(big.Int).Mod(denom, f).Sign() == 0 {
+			denom.Quo(denom, f)
+			count++
+		}
+		if count > digits {
+			digits = count
+		}
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return r.RatString()
+	}
+	return r.FloatString(digits)
+}
+
+// callBool converts a value to a boolean. Strings are parsed as "true" or "false" (also "1", "t",
+// "0", "f" and their upper case forms), and numbers are true when they are not zero.
+func callBool(arguments []interface{}) (interface{}, error) {
+
+	switch a := arguments[0].(type) {
+	case bool:
+		return a, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(a))
+		if err != nil {
+			return nil, fmt.Errorf("can't convert %q to a boolean", a)
+		}
+		return b, nil
+	case *big.Rat:
+		return a.Sign() != 0, nil
+	}
+
+	i64, bi, f64, kind := toNumeric(arguments[0])
+	switch kind {
+	case numInt64:
+		return i64 != 0, nil
+	case numBigInt:
+		return bi.Sign() != 0, nil
+	case numFloat64:
+		return f64 != 0, nil
+	default:
+		return nil, fmt.Errorf("can't convert type '%T' to a boolean", arguments[0])
+	}
+}
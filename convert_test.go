@@ -0,0 +1,125 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestConversionFunctions(t *testing.T) {
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"quantity": "42",
+		"price":    " 19.99 ",
+		"flag":     "TRUE",
+		"code":     "0xFF",
+		"speed":    -3.9,
+		"distance": 12,
+		"huge":     "123456789012345678901234567890",
+	}))
+
+	for _, expression := range []string{
+		`int(quantity) == 42 && int(code) == 255 && int('1_000') == 1000`,
+		`int(huge) > 123456789012345678901234567889 && int(true) == 1 && int(distance) == distance`,
+		`float(price) == 19.99 && float(distance) == 12 && float(false) == 0`,
+		`string(distance) == '12' && string(0.1) == '0.1' && string(true) == 'true' && string(quantity) == quantity`,
+		`string(semver('1.2.3')) == '1.2.3' && string(ip'10.0.0.1') == '10.0.0.1'`,
+		`bool(flag) && bool('0') == false && bool(distance) && !bool(0)`,
+		`int(quantity) > distance && float(price) < 20`,
+	} {
+		evaluate, err := NewExpression(expression)
+		if !assert.NoError(t, err, expression) {
+			continue
+		}
+		result, err := evaluate(data)
+		assert.NoError(t, err, expression)
+		assert.True(t, result, expression)
+	}
+
+	t.Run("integer conversion reads leading zeros as decimal", func(t *testing.T) {
+		for _, test := range []struct {
+			value    string
+			expected int64
+		}{
+			{"010", 10},
+			{"-007", -7},
+			{"+0_10", 10},
+			{"000", 0},
+			{"0", 0},
+			{"0x10", 16},
+			{"0b10", 2},
+			{"0o10", 8},
+			{"-0x10", -16},
+		} {
+			result, err := callInt([]interface{}{test.value})
+			if assert.NoError(t, err, test.value) {
+				assert.Equal(t, big.NewInt(test.expected), result, test.value)
+			}
+		}
+
+		_, err := callInt([]interface{}{"08x"})
+		assert.Error(t, err)
+	})
+
+	t.Run("integer conversion truncates toward zero", func(t *testing.T) {
+		for _, test := range []struct {
+			value    interface{}
+			expected int64
+		}{
+			{-3.9, -3},
+			{3.9, 3},
+			{big.NewRat(-7, 2), -3},
+			{big.NewRat(7, 2), 3},
+		} {
+			result, err := callInt([]interface{}{test.value})
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(test.expected), result, test.value)
+		}
+
+		result, err := callInt([]interface{}{1e30})
+		assert.NoError(t, err)
+		assert.Equal(t, "1000000000000000019884624838656", result.(*big.Int).String())
+	})
+
+	t.Run("decimals convert to exact strings", func(t *testing.T) {
+		for _, test := range []struct {
+			value    *big.Rat
+			expected string
+		}{
+			{big.NewRat(1999, 100), "19.99"},
+			{big.NewRat(1, 8), "0.125"},
+			{big.NewRat(-6, 3), "-2"},
+			{big.NewRat(1, 3), "1/3"},
+		} {
+			result, err := callString([]interface{}{test.value})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		}
+
+		result, err := callString([]interface{}{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-01-02T03:04:05Z", result)
+	})
+
+	t.Run("rejects invalid conversions", func(t *testing.T) {
+		for _, test := range []struct {
+			call     func([]interface{}) (interface{}, error)
+			argument interface{}
+		}{
+			{callInt, "12.5"},
+			{callInt, "twelve"},
+			{callInt, math.NaN()},
+			{callInt, math.Inf(1)},
+			{callFloat, "1e400"},
+			{callFloat, "abc"},
+			{callBool, "yes"},
+			{callString, []interface{}{"a"}},
+		} {
+			_, err := test.call([]interface{}{test.argument})
+			assert.Error(t, err, test.argument)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package boule
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/victordeleau/boule/internal/prefixtree"
+)
+
+// AddKeyValue adds a single identifier to the data.
+//
+// Keys must start with an ASCII letter (a-z, A-Z) and may only contain ASCII letters, digits
+// (0-9), underscores, and dots. Reserved keywords "true" and "false" are rejected.
+// Supported value types: bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32,
+// uint64, float32, float64, *big.Int, *big.Rat, *big.Float (stored as the *big.Rat of its shortest decimal), net.IP,
+// netip.Addr, *net.IPNet, netip.Prefix, semantic versions, time.Time, byte sizes, percentages
+// (stored as fractions), nil (null) and []interface{} lists of supported values, as decoded from
+// JSON. Values of named types whose underlying type is a bool, a string or a number are stored as
+// that type, and pointers to supported values are dereferenced.
+func (d *Data) AddKeyValue(key string, value interface{}) error {
+	value, err := normalizeValue(value)
+	if err != nil {
+		return err
+	}
+	return d.Tree.Insert(key, value)
+}
+
+// AddMap adds all entries from a map[string]interface{} to the data.
+// Keys and values follow the same rules as AddKeyValue.
+func (d *Data) AddMap(m map[string]interface{}) error {
+	for k, v := range m {
+		if err := d.AddKeyValue(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddStruct adds fields from a struct to the data. Field names are derived from json struct tags
+// (fields without a json tag are ignored). Nested structs are supported via dot notation (e.g.
+// "owner.name"). Slices and arrays of values are added as lists. Map fields, slices of structs, maps
+// or slices, and nil pointer and nil slice fields are skipped.
+func (d *Data) AddStruct(s interface{}) error {
+	fieldMap, err := prefixtree.StructValues(s, isValueType)
+	if err != nil {
+		return err
+	}
+	for k, v := range fieldMap {
+		if err := d.AddKeyValue(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deprecated: Add is kept for backward compatibility. Use AddKeyValue, AddMap, or AddStruct instead.
+func (d *Data) Add(input ...interface{}) error {
+	if len(input) == 1 {
+		if m, ok := input[0].(map[string]interface{}); ok {
+			return d.AddMap(m)
+		}
+		return d.AddStruct(input[0])
+	}
+	if len(input) == 2 {
+		key, ok := input[0].(string)
+		if !ok {
+			return fmt.Errorf("key must be of type string")
+		}
+		return d.AddKeyValue(key, input[1])
+	}
+	return fmt.Errorf("expected 1 or 2 arguments, got %d", len(input))
+}
+
+// normalizeValue checks that the type of value is supported as an identifier value, and returns
+// the value in the form expressions evaluate against. Pointers to supported values are
+// dereferenced.
+func normalizeValue(value interface{}) (interface{}, error) {
+
+	if value == nil {
+		return nil, nil
+	}
+
+	if list, ok := value.([]interface{}); ok {
+		normalized := make([]interface{}, 0, len(list))
+		for i, element := range list {
+			element, err := normalizeValue(element)
+			if err != nil {
+				return nil, fmt.Errorf("list element %d: %w", i, err)
+			}
+			normalized = append(normalized, element)
+		}
+		return normalized, nil
+	}
+
+	t, v := reflect.TypeOf(value), reflect.ValueOf(value)
+
+	if typ, ok := goTypes[t]; ok {
+		return typ.normalize(value)
+	}
+	if kind, ok := goKinds[t.Kind()]; ok {
+		return v.Convert(kind.goType).Interface(), nil
+	}
+	if t.Kind() == reflect.Ptr && isValueType(t.Elem()) {
+		if v.IsNil() {
+			return nil, fmt.Errorf("invalid nil %s", t)
+		}
+		return normalizeValue(v.Elem().Interface())
+	}
+
+	return nil, fmt.Errorf("'value' type %T is not supported", value)
+}
+
+// normalizeNumber checks the big numbers, *big.Float values being stored as the *big.Rat of their
+// shortest decimal, the way float64 values are read.
+func normalizeNumber(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, fmt.Errorf("invalid nil *big.Int")
+		}
+		return v, nil
+	case *big.Rat:
+		if v == nil {
+			return nil, fmt.Errorf("invalid nil *big.Rat")
+		}
+		return v, nil
+	case *big.Float:
+		if v == nil {
+			return nil, fmt.Errorf("invalid nil *big.Float")
+		}
+		if v.IsInf() {
+			return nil, fmt.Errorf("infinite *big.Float is not supported")
+		}
+		rat, _ := new(big.Rat).SetString(v.Text('g', -1))
+		return rat, nil
+	}
+	return nil, fmt.Errorf("'value' type %T is not supported", value)
+}
+
+// asIs returns the values of the supported types that are stored unchanged.
+func asIs(value interface{}) (interface{}, error) {
+	return value, nil
+}
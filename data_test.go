@@ -1,6 +1,13 @@
 package boule
 
-import "math/big"
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
 
 var testCases = []struct {
 	string      string
@@ -88,6 +95,170 @@ var testCases = []struct {
 		valid:  true,
 		result: true,
 	},
+	{
+		string:      `let heavy = cargo > 1000 in heavy && !cancelled || heavy && priority`,
+		tokenStream: []Token{LET, IDENT, ASSIGN, IDENT, GREATER, INTEGER, IN, IDENT, AND, NOT, IDENT, OR, IDENT, AND, IDENT},
+		data: map[string]interface{}{
+			"cargo":     2000,
+			"cancelled": true,
+			"priority":  true,
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `let far = distance > 100, late = delay > 5 && far in late || !far`,
+		tokenStream: []Token{LET, IDENT, ASSIGN, IDENT, GREATER, INTEGER, COMMA, IDENT, ASSIGN, IDENT, GREATER, INTEGER, AND, IDENT, IN, IDENT, OR, NOT, IDENT},
+		data: map[string]interface{}{
+			"distance": 50,
+			"delay":    10,
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `client_ip in cidr'10.0.0.0/8' && origin in ['Mars', "Titan"]`,
+		tokenStream: []Token{IDENT, IN, CIDR, AND, IDENT, IN, OPEN_LIST, STRING, COMMA, STRING, CLOSE_LIST},
+		data: map[string]interface{}{
+			"client_ip": net.ParseIP("10.20.30.40"),
+			"origin":    "Titan",
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `client_ip in ['192.168.0.0/16', '172.16.0.0/12'] || client_ip == ip'2001:db8::1'`,
+		tokenStream: []Token{IDENT, IN, OPEN_LIST, STRING, COMMA, STRING, CLOSE_LIST, OR, IDENT, EQUAL, IP},
+		data: map[string]interface{}{
+			"client_ip": netip.MustParseAddr("2001:db8::1"),
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `let local = (client_ip in [cidr'fd00::/8']) in !local`,
+		tokenStream: []Token{LET, IDENT, ASSIGN, OPEN, IDENT, IN, OPEN_LIST, CIDR, CLOSE_LIST, CLOSE, IN, NOT, IDENT},
+		data: map[string]interface{}{
+			"client_ip": netip.MustParseAddr("fd12::1"),
+		},
+		valid:  true,
+		result: false,
+	},
+	{
+		string:      `app_version >= semver('2.3.0') && app_version < semver("3.0.0-0")`,
+		tokenStream: []Token{IDENT, GREATER_OR_EQUAL, IDENT, OPEN, STRING, CLOSE, AND, IDENT, LESS, IDENT, OPEN, STRING, CLOSE},
+		data: map[string]interface{}{
+			"app_version": "2.12.0-rc.1",
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `register == 0xFF && size > 1_000_000 && mass < 6.02e23 && mode != 0b1010`,
+		tokenStream: []Token{IDENT, EQUAL, INTEGER, AND, IDENT, GREATER, INTEGER, AND, IDENT, LESS, FLOAT, AND, IDENT, NOT_EQUAL, INTEGER},
+		data: map[string]interface{}{
+			"register": 255,
+			"size":     2000000,
+			"mass":     5.97e22,
+			"mode":     0o17,
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `mode == 0755 && mode != 493 && day == 08`,
+		tokenStream: []Token{IDENT, EQUAL, INTEGER, AND, IDENT, NOT_EQUAL, INTEGER, AND, IDENT, EQUAL, INTEGER},
+		data: map[string]interface{}{
+			"mode": 755,
+			"day":  8,
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `flags & 0x04 != 0 && flags&^mask == 0b0100 && (flags | 1) >> 1 == 3 && 1 << 70 > flags ^ 3`,
+		tokenStream: []Token{IDENT, BIT_AND, INTEGER, NOT_EQUAL, INTEGER, AND, IDENT, BIT_AND_NOT, IDENT, EQUAL, INTEGER, AND, OPEN, IDENT, BIT_OR, INTEGER, CLOSE, SHIFT_RIGHT, INTEGER, EQUAL, INTEGER, AND, INTEGER, SHIFT_LEFT, INTEGER, GREATER, IDENT, BIT_XOR, INTEGER},
+		data: map[string]interface{}{
+			"flags": 0b0110,
+			"mask":  uint8(0b0010),
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `speed between 100 and 200 && altitude in 0..<5000 && name in 'A'..'M'`,
+		tokenStream: []Token{IDENT, BETWEEN, INTEGER, BETWEEN_AND, INTEGER, AND, IDENT, IN, INTEGER, RANGE_EXCLUSIVE, INTEGER, AND, IDENT, IN, STRING, RANGE, STRING},
+		data: map[string]interface{}{
+			"speed":    200,
+			"altitude": 4999.5,
+			"name":     "Europa",
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `departure in time('2024-01-01')..<time("2025-01-01") && arrival between departure and '2025-06-01T12:00:00Z'`,
+		tokenStream: []Token{IDENT, IN, IDENT, OPEN, STRING, CLOSE, RANGE_EXCLUSIVE, IDENT, OPEN, STRING, CLOSE, AND, IDENT, BETWEEN, IDENT, BETWEEN_AND, STRING},
+		data: map[string]interface{}{
+			"departure": time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC),
+			"arrival":   "2025-03-10T08:30:00+01:00",
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `host glob '*.eu.internal' && name like 'Sat%' && !(path glob "/static/*")`,
+		tokenStream: []Token{IDENT, GLOB, STRING, AND, IDENT, LIKE, STRING, AND, NOT, OPEN, IDENT, GLOB, STRING, CLOSE},
+		data: map[string]interface{}{
+			"host": "api.eu.internal",
+			"name": "Saturn",
+			"path": "/static/css/main.css",
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `lower(trim(destination)) == 'saturn' && len(crew) > 3 && pad_left(format('%d', id), 4, '0') == '0042'`,
+		tokenStream: []Token{IDENT, OPEN, IDENT, OPEN, IDENT, CLOSE, CLOSE, EQUAL, STRING, AND, IDENT, OPEN, IDENT, CLOSE, GREATER, INTEGER, AND, IDENT, OPEN, IDENT, OPEN, STRING, COMMA, IDENT, CLOSE, COMMA, INTEGER, COMMA, STRING, CLOSE, EQUAL, STRING},
+		data: map[string]interface{}{
+			"destination": " SATURN ",
+			"crew":        "Ripley",
+			"id":          42,
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `(reading is number && reading > 20) || reading is null && !(tags is list)`,
+		tokenStream: []Token{OPEN, IDENT, IS, IDENT, AND, IDENT, GREATER, INTEGER, CLOSE, OR, IDENT, IS, IDENT, AND, NOT, OPEN, IDENT, IS, IDENT, CLOSE},
+		data: map[string]interface{}{
+			"reading": nil,
+			"tags":    "eu",
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `temperature ~~ 21.7 && approx(pressure, 1013, 0.5)`,
+		tokenStream: []Token{IDENT, APPROX, FLOAT, AND, IDENT, OPEN, IDENT, COMMA, INTEGER, COMMA, FLOAT, CLOSE},
+		data: map[string]interface{}{
+			"temperature": 21.700000000000003,
+			"pressure":    1012.8,
+		},
+		valid:  true,
+		result: true,
+	},
+	{
+		string:      `memory_limit >= 1.5GiB && disk_free < 10% of disk_total`,
+		tokenStream: []Token{IDENT, GREATER_OR_EQUAL, INTEGER, AND, IDENT, LESS, FLOAT, OF, IDENT},
+		data: map[string]interface{}{
+			"memory_limit": 2147483648,
+			"disk_free":    ByteSize(20_000_000_000),
+			"disk_total":   ByteSize(500_000_000_000),
+		},
+		valid:  true,
+		result: true,
+	},
 
 	// invalid tests
 	{
@@ -124,4 +295,233 @@ var testCases = []struct {
 		data:        map[string]interface{}{},
 		valid:       false,
 	},
+	{
+		string:      `let a = b, b = a in a`,
+		tokenStream: []Token{LET, IDENT, ASSIGN, IDENT, COMMA, IDENT, ASSIGN, IDENT, IN, IDENT},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `let a = x == 1 a`,
+		tokenStream: []Token{LET, IDENT, ASSIGN, IDENT, EQUAL, INTEGER, IDENT},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `client_ip in cidr'10.0.0.0/33'`,
+		tokenStream: []Token{IDENT, IN, CIDR},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `flags & & 0x04`,
+		tokenStream: []Token{IDENT, BIT_AND, BIT_AND, INTEGER},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `speed between 100 200`,
+		tokenStream: []Token{IDENT, BETWEEN, INTEGER, INTEGER},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `speed in 1..2..3`,
+		tokenStream: []Token{IDENT, IN, INTEGER, RANGE, INTEGER, RANGE, INTEGER},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `host glob '[a-'`,
+		tokenStream: []Token{IDENT, GLOB, STRING},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `~ temperature == 21.7`,
+		tokenStream: []Token{ILLEGAL, IDENT, EQUAL, FLOAT},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+	{
+		string:      `origin in ['Mars', 'Titan'`,
+		tokenStream: []Token{IDENT, IN, OPEN_LIST, STRING, COMMA, STRING},
+		data:        map[string]interface{}{},
+		valid:       false,
+	},
+}
+
+func TestData_AddKeyValue(t *testing.T) {
+
+	t.Run("can add key/value pair", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("some_key", 380))
+		value, err := data.Find("some_key")
+		if assert.NoError(t, err) {
+			if assert.IsType(t, 10, value) {
+				assert.Equal(t, 380, value)
+			}
+		}
+	})
+
+	t.Run("rejects unsupported value type", func(t *testing.T) {
+		assert.Error(t, NewData().AddKeyValue("key", []int{1, 2}))
+		assert.Error(t, NewData().AddKeyValue("key", struct{}{}))
+	})
+
+	t.Run("stores values of named types as the predeclared type", func(t *testing.T) {
+		type Speed int
+		type Color string
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", Speed(3)))
+		assert.NoError(t, data.AddStruct(struct {
+			Color Color `json:"color"`
+		}{Color: "red"}))
+
+		value, err := data.Find("speed")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, value)
+		}
+		value, err = data.Find("color")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "red", value)
+		}
+	})
+
+	t.Run("stores big.Float as the big.Rat of its shortest decimal", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("price", big.NewFloat(0.25)))
+
+		value, err := data.Find("price")
+		if assert.NoError(t, err) {
+			assert.Equal(t, big.NewRat(1, 4), value)
+		}
+
+		assert.NoError(t, data.AddKeyValue("rate", big.NewFloat(0.1)))
+		value, err = data.Find("rate")
+		if assert.NoError(t, err) {
+			assert.Equal(t, big.NewRat(1, 10), value)
+		}
+
+		assert.Error(t, data.AddKeyValue("infinite", new(big.Float).SetInf(false)))
+	})
+
+	t.Run("rejects nil big numbers", func(t *testing.T) {
+		data := NewData()
+		assert.Error(t, data.AddKeyValue("float", (*big.Float)(nil)))
+		assert.Error(t, data.AddKeyValue("rat", (*big.Rat)(nil)))
+		assert.Error(t, data.AddKeyValue("int", (*big.Int)(nil)))
+	})
+
+	t.Run("dereferences time pointers", func(t *testing.T) {
+		data := NewData()
+		departure := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		assert.NoError(t, data.AddKeyValue("departure", &departure))
+		value, err := data.Find("departure")
+		if assert.NoError(t, err) {
+			assert.Equal(t, departure, value)
+		}
+		assert.Error(t, data.AddKeyValue("arrival", (*time.Time)(nil)))
+	})
+
+	t.Run("supports null and lists of supported values", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("missing", nil))
+		assert.NoError(t, data.AddKeyValue("tags", []interface{}{"eu", 2.5, nil}))
+
+		value, err := data.Find("missing")
+		if assert.NoError(t, err) {
+			assert.Nil(t, value)
+		}
+		value, err = data.Find("tags")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []interface{}{"eu", 2.5, nil}, value)
+		}
+
+		assert.Error(t, data.AddKeyValue("nested", []interface{}{map[string]interface{}{"a": 1}}))
+	})
+}
+
+func TestData_AddMap(t *testing.T) {
+
+	t.Run("can add map as data", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{
+			"road":   "Wellington",
+			"number": 20,
+		}))
+
+		value, err := data.Find("road")
+		if assert.NoError(t, err) {
+			if assert.IsType(t, "string", value) {
+				assert.Equal(t, "Wellington", value)
+			}
+		}
+
+		value, err = data.Find("number")
+		if assert.NoError(t, err) {
+			if assert.IsType(t, 0, value) {
+				assert.Equal(t, 20, value)
+			}
+		}
+	})
+
+	t.Run("map can't index slice", func(t *testing.T) {
+		assert.Error(t, NewData().AddMap(map[string]interface{}{
+			"index": []int{0, 1, 2},
+		}))
+	})
+
+	t.Run("map can't index map", func(t *testing.T) {
+		assert.Error(t, NewData().AddMap(map[string]interface{}{
+			"index": map[string]int{"un": 1, "deux": 2},
+		}))
+	})
+
+	t.Run("rejects reserved keyword in map", func(t *testing.T) {
+		assert.Error(t, NewData().AddMap(map[string]interface{}{
+			"true": 1,
+		}))
+	})
+}
+
+func TestData_AddBackwardCompat(t *testing.T) {
+
+	t.Run("key/value via Add", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.Add("some_key", 380))
+		value, err := data.Find("some_key")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 380, value)
+		}
+	})
+
+	t.Run("map via Add", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.Add(map[string]interface{}{"road": "Wellington"}))
+		value, err := data.Find("road")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Wellington", value)
+		}
+	})
+
+	t.Run("struct via Add", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.Add(struct {
+			Road string `json:"road"`
+		}{Road: "Wellington"}))
+		value, err := data.Find("road")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Wellington", value)
+		}
+	})
+
+	t.Run("wrong arg count via Add", func(t *testing.T) {
+		assert.Error(t, NewData().Add("a", 1, "extra"))
+	})
+
+	t.Run("non-string key via Add", func(t *testing.T) {
+		assert.Error(t, NewData().Add(3, 380))
+	})
 }
@@ -0,0 +1,66 @@
+package boule
+
+import (
+	"fmt"
+	"time"
+)
+
+// parseTime parses a time in RFC 3339 format, or a date in the YYYY-MM-DD format, which is
+// midnight UTC.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+func callTime(arguments []interface{}) (interface{}, error) {
+	switch a := arguments[0].(type) {
+	case string:
+		return parseTime(a)
+	case time.Time:
+		return a, nil
+	default:
+		return nil, fmt.Errorf("expected argument of type 'string', got '%T'", a)
+	}
+}
+
+// compareTime compares a time with another time, which may be given in its string form.
+func compareTime(left, right interface{}, token Token, position int) (interface{}, error) {
+
+	var times [2]time.Time
+
+	for i, operand := range []interface{}{left, right} {
+		switch o := operand.(type) {
+		case time.Time:
+			times[i] = o
+		case string:
+			t, err := parseTime(o)
+			if err != nil {
+				return false, newTypeError(position, "can't compare time with malformed time %q", o)
+			}
+			times[i] = t
+		default:
+			return false, newTypeError(position, "can't compare type '%T' with type '%T'", left, right)
+		}
+	}
+
+	c := times[0].Compare(times[1])
+
+	switch token {
+	case EQUAL:
+		return c == 0, nil
+	case NOT_EQUAL:
+		return c != 0, nil
+	case LESS:
+		return c < 0, nil
+	case LESS_OR_EQUAL:
+		return c <= 0, nil
+	case GREATER:
+		return c > 0, nil
+	case GREATER_OR_EQUAL:
+		return c >= 0, nil
+	default:
+		return false, newTypeError(position, "type 'time' only supports the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
+	}
+}
@@ -0,0 +1,126 @@
+package boule
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/victordeleau/boule/internal/prefixtree"
+)
+
+// SyntaxError is returned when an expression can't be parsed. Pos and End are the byte offsets of
+// the span of the offending token in the expression, End being exclusive.
+type SyntaxError struct {
+	Msg   string
+	Pos   int
+	End   int
+	Token Token // offending token, EOF if the expression ended early
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid syntax: %s (position=%d)", e.Msg, e.Pos)
+}
+
+// TypeError is returned when the operands of an operator, or the value of an expression, are of
+// the wrong type. Pos and End are the byte offsets of the span of the offending expression, End
+// being exclusive.
+type TypeError struct {
+	Msg   string
+	Pos   int
+	End   int
+	Token Token // operator whose operands are of the wrong type, EOF if none
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("%s (position=%d)", e.Msg, e.Pos)
+}
+
+// UnknownIdentifierError is returned when an identifier of the expression isn't found in the data.
+// Pos and End are the byte offsets of the span of the identifier, End being exclusive.
+type UnknownIdentifierError struct {
+	Name string
+	Pos  int
+	End  int
+}
+
+func (e *UnknownIdentifierError) Error() string {
+	return fmt.Sprintf("unknown identifier '%s' (position=%d)", e.Name, e.Pos)
+}
+
+func (e *UnknownIdentifierError) Unwrap() error {
+	return prefixtree.ErrPrefixNotFound
+}
+
+// AmbiguousIdentifierError is returned when an identifier of the expression is a prefix of more
+// than one key of the data. Pos and End are the byte offsets of the span of the identifier, End
+// being exclusive.
+type AmbiguousIdentifierError struct {
+	Name string
+	Pos  int
+	End  int
+}
+
+func (e *AmbiguousIdentifierError) Error() string {
+	return fmt.Sprintf("ambiguous identifier '%s' (position=%d)", e.Name, e.Pos)
+}
+
+func (e *AmbiguousIdentifierError) Unwrap() error {
+	return prefixtree.ErrPrefixAmbiguous
+}
+
+// UnboundParameterError is returned when a placeholder of the expression isn't bound to a value.
+// Pos and End are the byte offsets of the span of the placeholder, End being exclusive.
+type UnboundParameterError struct {
+	Name string // name of the placeholder, including its sigil, e.g. ":threshold" or "$1"
+	Pos  int
+	End  int
+}
+
+func (e *UnboundParameterError) Error() string {
+	return fmt.Sprintf("parameter '%s' is not bound (position=%d)", e.Name, e.Pos)
+}
+
+func newSyntaxError(token *lexerTokenWithPosition, format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{
+		Msg:   fmt.Sprintf(format, args...),
+		Pos:   token.position,
+		End:   token.end,
+		Token: token.token,
+	}
+}
+
+// newTypeError returns a type error raised by an operator at the given position. Its span is set
+// by the expression evaluating the operator, with locate.
+func newTypeError(position int, format string, args ...interface{}) *TypeError {
+	return &TypeError{
+		Msg: fmt.Sprintf(format, args...),
+		Pos: position,
+	}
+}
+
+// locate sets the span of a type error raised by the operator of a node to the span of the node.
+func locate(err error, node Node, token Token) error {
+	var typeError *TypeError
+	if errors.As(err, &typeError) && typeError.End == 0 {
+		typeError.Pos, typeError.End, typeError.Token = node.Pos(), node.End(), token
+	}
+	return err
+}
+
+// ErrorList is the list of the errors found when parsing an expression, in the order they were
+// found, returned when there are more than one.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Unwrap returns the errors of the list, for errors.Is and errors.As to look into them.
+func (l ErrorList) Unwrap() []error {
+	return l
+}
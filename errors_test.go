@@ -0,0 +1,155 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+
+	evaluate := func(t *testing.T, expression string, data map[string]interface{}) error {
+		evaluate, err := NewExpression(expression)
+		if !assert.NoError(t, err, expression) {
+			return nil
+		}
+		d := NewData()
+		assert.NoError(t, d.AddMap(data))
+		_, err = evaluate(d)
+		return err
+	}
+
+	t.Run("syntax errors span the offending token", func(t *testing.T) {
+		for _, test := range []struct {
+			expression string
+			span       string
+			token      Token
+		}{
+			{expression: `speed > )`, span: `)`, token: CLOSE},
+			{expression: `speed between 1 or 2`, span: `or`, token: IDENT},
+			{expression: `speed > 0xZZ`, span: `0xZZ`, token: ILLEGAL},
+			{expression: `unknown(1)`, span: `unknown`, token: IDENT},
+			{expression: `abs(1, 2)`, span: `abs(1, 2)`, token: IDENT},
+			{expression: `let a = 1, a = 2 in a`, span: `a`, token: IDENT},
+			{expression: `reading is text`, span: `text`, token: IDENT},
+			{expression: `ip in cidr'::ffff:0:0/80'`, span: `cidr'::ffff:0:0/80'`, token: CIDR},
+			{expression: `name like 'a\'`, span: `'a\'`, token: STRING},
+			{expression: `speed > `, span: ``, token: EOF},
+		} {
+			_, err := Compile(test.expression)
+
+			var syntaxError *SyntaxError
+			if assert.True(t, errors.As(err, &syntaxError), "%s: %v", test.expression, err) {
+				assert.Equal(t, test.span, test.expression[syntaxError.Pos:syntaxError.End], test.expression)
+				assert.Equal(t, test.token, syntaxError.Token, test.expression)
+				assert.Contains(t, err.Error(), "invalid syntax: ", test.expression)
+			}
+		}
+	})
+
+	t.Run("type errors span the offending expression", func(t *testing.T) {
+		data := map[string]interface{}{"speed": 20, "name": "Titan", "tags": []interface{}{"a"}, "shift": -1}
+
+		for _, test := range []struct {
+			expression string
+			span       string
+			token      Token
+		}{
+			{expression: `name == 'Titan' && speed > 'fast'`, span: `speed > 'fast'`, token: GREATER},
+			{expression: `!speed`, span: `!speed`, token: NOT},
+			{expression: `speed in 10`, span: `speed in 10`, token: IN},
+			{expression: `speed between 'a' and 'b' || true`, span: `speed between 'a' and 'b'`, token: BETWEEN},
+			{expression: `speed like 'a%'`, span: `speed like 'a%'`, token: LIKE},
+			{expression: `(name & 1) == 0`, span: `name & 1`, token: BIT_AND},
+			{expression: `(speed << shift) == 0`, span: `speed << shift`, token: SHIFT_LEFT},
+			{expression: `pad_left(name, speed, '') == name`, span: `pad_left(name, speed, '')`, token: EOF},
+			{expression: `speed`, span: `speed`, token: EOF},
+		} {
+			err := evaluate(t, test.expression, data)
+
+			var typeError *TypeError
+			if assert.True(t, errors.As(err, &typeError), "%s: %v", test.expression, err) {
+				assert.Equal(t, test.span, test.expression[typeError.Pos:typeError.End], test.expression)
+				assert.Equal(t, test.token, typeError.Token, test.expression)
+			}
+		}
+
+		_, err := Compile(`name like 42`)
+		var typeError *TypeError
+		if assert.True(t, errors.As(err, &typeError), "%v", err) {
+			assert.Equal(t, LIKE, typeError.Token)
+		}
+
+		expression := `pad_left('Titan', 8, '') == name`
+		_, err = Compile(expression)
+		if assert.True(t, errors.As(err, &typeError), "%v", err) {
+			assert.Equal(t, `pad_left('Titan', 8, '')`, expression[typeError.Pos:typeError.End])
+		}
+	})
+
+	t.Run("parameter type errors span the placeholder", func(t *testing.T) {
+		expression := `speed > :limit && :limit == true`
+		_, err := Compile(expression)
+
+		var typeError *TypeError
+		if assert.True(t, errors.As(err, &typeError), "%v", err) {
+			assert.Equal(t, ":limit", expression[typeError.Pos:typeError.End])
+			assert.Equal(t, 18, typeError.Pos)
+		}
+
+		evaluate, err := NewParameterizedExpression(`speed > :limit && :limit < 100`)
+		if assert.NoError(t, err) {
+			params := NewParams()
+			assert.NoError(t, params.Set("limit", "fast"))
+			_, err = evaluate(NewData(), params)
+			if assert.True(t, errors.As(err, &typeError), "%v", err) {
+				assert.Equal(t, 8, typeError.Pos)
+			}
+		}
+	})
+
+	t.Run("unknown identifier", func(t *testing.T) {
+		expression := `speed > 10 && destination == 'Titan'`
+		err := evaluate(t, expression, map[string]interface{}{"speed": 20})
+
+		var unknown *UnknownIdentifierError
+		if assert.True(t, errors.As(err, &unknown), "%v", err) {
+			assert.Equal(t, "destination", unknown.Name)
+			assert.Equal(t, "destination", expression[unknown.Pos:unknown.End])
+		}
+	})
+
+	t.Run("ambiguous identifier", func(t *testing.T) {
+		expression := `ship > 10`
+		err := evaluate(t, expression, map[string]interface{}{"ship.speed": 20, "ship.mass": 10})
+
+		var ambiguous *AmbiguousIdentifierError
+		if assert.True(t, errors.As(err, &ambiguous), "%v", err) {
+			assert.Equal(t, "ship", ambiguous.Name)
+			assert.Equal(t, 0, ambiguous.Pos)
+			assert.Equal(t, 4, ambiguous.End)
+		}
+	})
+
+	t.Run("let bindings resolved outside of their scope", func(t *testing.T) {
+		let := &LetExpression{}
+		binding := &Binding{name: "a", value: &LiteralInteger{}, let: let}
+		reference := &LiteralIdent{identifier: "a", binding: binding, position: 4, end: 5}
+
+		_, err := reference.Evaluate(NewData())
+		var unknown *UnknownIdentifierError
+		if assert.True(t, errors.As(err, &unknown), "%v", err) {
+			assert.Equal(t, "a", unknown.Name)
+			assert.Equal(t, 4, unknown.Pos)
+			assert.Equal(t, 5, unknown.End)
+		}
+
+		env := &environment{options: &options{}, frame: &frame{let: let, results: []*bindingResult{{evaluating: true}}}}
+		_, err = reference.Evaluate(NewData().with(env))
+		var syntaxError *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxError), "%v", err) {
+			assert.Equal(t, 4, syntaxError.Pos)
+			assert.Equal(t, 5, syntaxError.End)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package boule
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Expression is a compiled boolean expression. It can be evaluated repeatedly against different
+// Data, printed, and stored in text-based formats such as JSON or YAML, where it is represented by
+// its source.
+//
+// The zero Expression is not valid until it is unmarshalled.
+type Expression struct {
+	source string
+	ast    *AST
+	opts   []Option // options the expression is compiled with, kept to unmarshal it again
+}
+
+// Compile parses a boolean expression string and returns an Expression that can be evaluated.
+func Compile(input string, opts ...Option) (*Expression, error) {
+
+	ast, err := parse(input, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expression{source: input, ast: ast, opts: opts}, nil
+}
+
+// Uncompiled returns an Expression that isn't valid until it is unmarshalled, and is then compiled
+// with the options, e.g. to decode rules stored in configuration against a schema.
+func Uncompiled(opts ...Option) *Expression {
+	return &Expression{opts: opts}
+}
+
+// MustCompile is like Compile but panics if the expression can't be parsed.
+func MustCompile(input string, opts ...Option) *Expression {
+
+	expression, err := Compile(input, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("boule: Compile(%q): %v", input, err))
+	}
+
+	return expression
+}
+
+// Evaluate evaluates the expression against the data. Expressions containing placeholders fail
+// with an UnboundParameterError: use EvaluateWithParams for them.
+func (e *Expression) Evaluate(data *Data) (bool, error) {
+	return e.EvaluateWithParams(data, nil)
+}
+
+// EvaluateWithParams evaluates the expression against the data, with its placeholders bound to the
+// given parameters. The bound values are checked against the types inferred for the placeholders.
+func (e *Expression) EvaluateWithParams(data *Data, params *Params) (bool, error) {
+
+	if e.ast == nil {
+		return false, fmt.Errorf("expression is not compiled")
+	}
+
+	if err := e.ast.checkParams(params); err != nil {
+		return false, err
+	}
+
+	return e.ast.evaluate(data, params)
+}
+
+// Source returns the expression string the expression was compiled from.
+func (e *Expression) Source() string {
+	return e.source
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Root returns the root node of the syntax tree of the expression.
+func (e *Expression) Root() Node {
+	if e.ast == nil {
+		return nil
+	}
+	return e.ast.program
+}
+
+// Identifiers returns the sorted, deduplicated identifiers the expression looks up in the data.
+// Let bindings, the boolean keywords and function names are not included.
+func (e *Expression) Identifiers() []string {
+
+	root := e.Root()
+	if root == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	Inspect(root, func(node Node) bool {
+		if ident, ok := node.(*LiteralIdent); ok && ident.binding == nil && ident.identifier != "true" && ident.identifier != "false" {
+			seen[ident.identifier] = struct{}{}
+		}
+		return true
+	})
+
+	identifiers := make([]string, 0, len(seen))
+	for identifier := range seen {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	return identifiers
+}
+
+// MarshalText implements encoding.TextMarshaler, and returns the source of the expression.
+func (e *Expression) MarshalText() ([]byte, error) {
+	return []byte(e.source), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, and compiles the expression from its source
+// with the options the expression was compiled with, or created with by Uncompiled. The zero
+// Expression is compiled with the default options.
+func (e *Expression) UnmarshalText(text []byte) error {
+	return e.UnmarshalTextWith(text, e.opts...)
+}
+
+// UnmarshalTextWith compiles the expression from its source with the given options, which replace
+// the options the expression was compiled with.
+func (e *Expression) UnmarshalTextWith(text []byte, opts ...Option) error {
+
+	expression, err := Compile(string(text), opts...)
+	if err != nil {
+		return err
+	}
+
+	*e = *expression
+	return nil
+}
@@ -0,0 +1,135 @@
+package boule
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExpression(t *testing.T) {
+
+	t.Run("evaluates against data", func(t *testing.T) {
+		expression, err := Compile(`speed > 10 && destination == 'Titan'`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{"speed": 20, "destination": "Titan"}))
+
+		result, err := expression.Evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+
+	t.Run("evaluates with parameters", func(t *testing.T) {
+		expression := MustCompile(`speed > :limit`)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", 20))
+
+		params := NewParams()
+		assert.NoError(t, params.Set("limit", 10))
+
+		result, err := expression.EvaluateWithParams(data, params)
+		assert.NoError(t, err)
+		assert.True(t, result)
+
+		_, err = expression.Evaluate(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the source", func(t *testing.T) {
+		source := `speed >   10 && !cancelled`
+		expression := MustCompile(source)
+		assert.Equal(t, source, expression.Source())
+		assert.Equal(t, source, expression.String())
+	})
+
+	t.Run("lists the identifiers looked up in the data", func(t *testing.T) {
+		expression := MustCompile(`let fast = speed > 10 in fast && lower(name) == 'titan' && true || speed < limit`)
+		assert.Equal(t, []string{"limit", "name", "speed"}, expression.Identifiers())
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := Compile(`speed >`)
+		assert.Error(t, err)
+		assert.Panics(t, func() { MustCompile(`speed >`) })
+	})
+
+	t.Run("round-trips through JSON", func(t *testing.T) {
+		type rule struct {
+			Name      string      `json:"name"`
+			Condition *Expression `json:"condition"`
+		}
+
+		encoded, err := json.Marshal(rule{Name: "fast", Condition: MustCompile(`speed > 10`)})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.JSONEq(t, `{"name": "fast", "condition": "speed > 10"}`, string(encoded))
+
+		var decoded rule
+		if !assert.NoError(t, json.Unmarshal(encoded, &decoded)) {
+			return
+		}
+		assert.Equal(t, "speed > 10", decoded.Condition.Source())
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", 20))
+		result, err := decoded.Condition.Evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result)
+
+		assert.Error(t, json.Unmarshal([]byte(`{"condition": "speed >"}`), &decoded))
+	})
+
+	t.Run("keeps its options when unmarshalled", func(t *testing.T) {
+		type rule struct {
+			Condition *Expression `json:"condition"`
+		}
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("price", 0.3))
+
+		// equal as float64, but not as exact decimals
+		decimal := MustCompile(`price == 0.30000000000000001`, WithDecimal())
+		encoded, err := json.Marshal(rule{Condition: decimal})
+		assert.NoError(t, err)
+
+		decoded := rule{Condition: Uncompiled()}
+		assert.NoError(t, json.Unmarshal(encoded, &decoded))
+		result, err := decoded.Condition.Evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result, "float equality by default")
+
+		decoded = rule{Condition: Uncompiled(WithDecimal())}
+		assert.NoError(t, json.Unmarshal(encoded, &decoded))
+		result, err = decoded.Condition.Evaluate(data)
+		assert.NoError(t, err)
+		assert.False(t, result, "decimal equality")
+
+		text, err := decimal.MarshalText()
+		assert.NoError(t, err)
+		assert.NoError(t, decimal.UnmarshalText(text))
+		result, err = decimal.Evaluate(data)
+		assert.NoError(t, err)
+		assert.False(t, result, "options of the compiled expression")
+
+		schema := NewSchema()
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+		assert.Error(t, json.Unmarshal([]byte(`{"condition": "sped > 10"}`), &rule{Condition: Uncompiled(WithSchema(schema))}))
+		assert.NoError(t, json.Unmarshal([]byte(`{"condition": "sped > 10"}`), &rule{}))
+
+		var expression Expression
+		assert.Error(t, expression.UnmarshalTextWith([]byte(`sped > 10`), WithSchema(schema)))
+	})
+
+	t.Run("zero expression can't be evaluated", func(t *testing.T) {
+		var expression Expression
+		_, err := expression.Evaluate(NewData())
+		assert.Error(t, err)
+		assert.Nil(t, expression.Root())
+		assert.Empty(t, expression.Identifiers())
+	})
+}
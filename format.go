@@ -0,0 +1,173 @@
+package boule
+
+import "strings"
+
+// primary is the precedence of the nodes that never need parentheses: literals, calls, lists and
+// unary expressions.
+const primary = 8
+
+// Format renders a syntax tree back to source in canonical form: operators are surrounded by single
+// spaces, strings are single-quoted, and only the parentheses required by precedence are kept.
+// Number literals are written as in the source, e.g. 0xFF or 512MB. The formatted source parses
+// back to the same tree, without its grouping expressions.
+func Format(node Node) string {
+	p := &printer{}
+	p.node(node, 0)
+	return p.String()
+}
+
+type printer struct {
+	strings.Builder
+	noIn bool // IN must be parenthesized, as it would end the enclosing let binding
+}
+
+// precedenceOf returns the precedence of a node, parentheses being required where the node is used
+// as an operand that must bind tighter.
+func precedenceOf(node Node) int {
+	switch n := node.(type) {
+	case *GroupingExpression:
+		return precedenceOf(n.Node)
+	case *BinaryExpression:
+		return n.token.Precedence()
+	case *BetweenExpression:
+		return BETWEEN.Precedence()
+	case *RangeExpression:
+		return RANGE.Precedence()
+	case *MatchExpression:
+		return n.token.Precedence()
+	case *TypeTestExpression:
+		return IS.Precedence()
+	case *LetExpression:
+		return 0
+	}
+	return primary
+}
+
+// node writes a node that must have at least the given precedence to be written without parentheses.
+func (p *printer) node(node Node, precedence int) {
+
+	if grouping, ok := node.(*GroupingExpression); ok {
+		p.node(grouping.Node, precedence)
+		return
+	}
+
+	if binary, ok := node.(*BinaryExpression); precedenceOf(node) < precedence || (ok && binary.token == IN && p.noIn) {
+		p.group(func() { p.node(node, 0) })
+		return
+	}
+
+	switch n := node.(type) {
+	case *BinaryExpression:
+		left, right := n.token.Precedence(), n.token.Precedence()+1
+		if n.token.BooleanOperator() { // right-associative
+			left, right = right, left
+		}
+		p.node(n.left, left)
+		p.operator(n.token)
+		p.node(n.right, right)
+	case *UnaryExpression:
+		p.WriteString(NOT.String())
+		p.node(n.Node, primary)
+	case *BetweenExpression:
+		p.node(n.value, BETWEEN.Precedence())
+		p.operator(BETWEEN)
+		p.node(n.low, BETWEEN.Precedence()+1)
+		p.operator(BETWEEN_AND)
+		p.node(n.high, BETWEEN.Precedence()+1)
+	case *RangeExpression:
+		p.node(n.low, RANGE.Precedence()+1) // range bounds can't be ranges
+		if n.exclusive {
+			p.WriteString(RANGE_EXCLUSIVE.String())
+		} else {
+			p.WriteString(RANGE.String())
+		}
+		p.node(n.high, RANGE.Precedence()+1)
+	case *MatchExpression:
+		p.node(n.value, n.token.Precedence())
+		p.operator(n.token)
+		p.node(n.pattern, n.token.Precedence()+1)
+	case *TypeTestExpression:
+		p.node(n.value, IS.Precedence())
+		p.operator(IS)
+		p.WriteString(n.typeName)
+	case *LetExpression:
+		p.WriteString(LET.String())
+		p.WriteByte(' ')
+		for i, b := range n.bindings {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.WriteString(b.name)
+			p.operator(ASSIGN)
+			noIn := p.noIn
+			p.noIn = true
+			p.node(b.value, 0)
+			p.noIn = noIn
+		}
+		p.operator(IN)
+		p.node(n.Node, 0)
+	case *CallExpression:
+		p.WriteString(n.name)
+		p.WriteString(OPEN.String())
+		p.nodes(n.arguments)
+		p.WriteString(CLOSE.String())
+	case *ListExpression:
+		p.WriteString(OPEN_LIST.String())
+		p.nodes(n.elements)
+		p.WriteString(CLOSE_LIST.String())
+	case *LiteralInteger:
+		p.WriteString(n.raw)
+	case *LiteralFloat:
+		p.WriteString(n.raw)
+	case *LiteralDecimal:
+		p.WriteString(n.raw)
+	case *LiteralString:
+		p.quote(n.value)
+	case *LiteralIP:
+		p.WriteString("ip")
+		p.quote(n.value.String())
+	case *LiteralCIDR:
+		p.WriteString("cidr")
+		p.quote(n.value.String())
+	case *LiteralIdent:
+		p.WriteString(n.identifier)
+	case *Parameter:
+		p.WriteString(n.name)
+	}
+}
+
+// group writes parentheses around what f writes, where IN is allowed again.
+func (p *printer) group(f func()) {
+	noIn := p.noIn
+	p.noIn = false
+	p.WriteString(OPEN.String())
+	f()
+	p.WriteString(CLOSE.String())
+	p.noIn = noIn
+}
+
+// nodes writes a comma-separated sequence of nodes, such as call arguments or list elements.
+func (p *printer) nodes(nodes []Node) {
+	noIn := p.noIn
+	p.noIn = false
+	for i, node := range nodes {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		p.node(node, 0)
+	}
+	p.noIn = noIn
+}
+
+func (p *printer) operator(token Token) {
+	p.WriteByte(' ')
+	p.WriteString(token.String())
+	p.WriteByte(' ')
+}
+
+// quote writes a string literal. Strings can't contain quotes, which end them.
+func (p *printer) quote(s string) {
+	p.WriteByte('\'')
+	p.WriteString(s)
+	p.WriteByte('\'')
+}
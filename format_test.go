@@ -0,0 +1,121 @@
+package boule
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// shape renders the structure of a tree with every operation parenthesized and without its
+// grouping expressions, to compare trees regardless of their formatting.
+func shape(node Node) string {
+	switch n := node.(type) {
+	case *GroupingExpression:
+		return shape(n.Inner())
+	case *BinaryExpression:
+		return fmt.Sprintf("(%s %s %s)", shape(n.Left()), n.Operator(), shape(n.Right()))
+	case *UnaryExpression:
+		return fmt.Sprintf("(! %s)", shape(n.Operand()))
+	case *BetweenExpression:
+		return fmt.Sprintf("(%s between %s %s)", shape(n.Value()), shape(n.Low()), shape(n.High()))
+	case *RangeExpression:
+		return fmt.Sprintf("(%s .. %s %t)", shape(n.Low()), shape(n.High()), n.Exclusive())
+	case *MatchExpression:
+		return fmt.Sprintf("(%s %s %s)", shape(n.Value()), n.Operator(), shape(n.Pattern()))
+	case *TypeTestExpression:
+		return fmt.Sprintf("(%s is %s)", shape(n.Value()), n.TypeName())
+	case *LetExpression:
+		var bindings []string
+		for _, b := range n.Bindings() {
+			bindings = append(bindings, b.Name()+"="+shape(b.Value()))
+		}
+		return fmt.Sprintf("(let %s %s)", strings.Join(bindings, " "), shape(n.Body()))
+	case *CallExpression:
+		return fmt.Sprintf("%s(%s)", n.Name(), shapes(n.Arguments()))
+	case *ListExpression:
+		return fmt.Sprintf("[%s]", shapes(n.Elements()))
+	case *LiteralIdent:
+		return n.Name()
+	case *Parameter:
+		return n.Name()
+	}
+	value, _ := node.Evaluate(nil)
+	return fmt.Sprintf("%T:%v", value, value)
+}
+
+func shapes(nodes []Node) string {
+	var s []string
+	for _, node := range nodes {
+		s = append(s, shape(node))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestFormat(t *testing.T) {
+
+	for _, test := range []struct {
+		input    string
+		expected string
+	}{
+		{input: `a==1&&(b)`, expected: `a == 1 && b`},
+		{input: `((a || b)) && c`, expected: `(a || b) && c`},
+		{input: `a || (b && c)`, expected: `a || b && c`},
+		{input: `(a || b) || c`, expected: `(a || b) || c`},
+		{input: `a || (b || c)`, expected: `a || b || c`},
+		{input: `(a && b) || c`, expected: `(a && b) || c`},
+		{input: `!(a)&&!(b==c)`, expected: `!a && !(b == c)`},
+		{input: `!!a`, expected: `!!a`},
+		{input: `flags&(0x0F|mask)!=0`, expected: `flags & (0x0F | mask) != 0`},
+		{input: `size>512MB&&ratio<=20%  of  total`, expected: `size > 512MB && ratio <= 20% of total`},
+		{input: `speed>1_000.50 && x == 6.02e23`, expected: `speed > 1_000.50 && x == 6.02e23`},
+		{input: `name=="Europa"`, expected: `name == 'Europa'`},
+		{input: `client in ip"10.0.0.1" || client in cidr'10.1.2.3/8'`, expected: `client in ip'10.0.0.1' || client in cidr'10.0.0.0/8'`},
+		{input: `port in [ 22,80 , 443 ]&&tags==[]`, expected: `port in [22, 80, 443] && tags == []`},
+		{input: `max( a,b )>lower( 'X' )`, expected: `max(a, b) > lower('X')`},
+		{input: `speed between(10)and 20`, expected: `speed between 10 and 20`},
+		{input: `speed in 1 ..< 10 && name in 'A'..'M'`, expected: `speed in 1..<10 && name in 'A'..'M'`},
+		{input: `name like'eu%'||path glob '*.go'`, expected: `name like 'eu%' || path glob '*.go'`},
+		{input: `reading is   null`, expected: `reading is null`},
+		{input: `reading ~~21.7`, expected: `reading ~~ 21.7`},
+		{input: `speed>:limit&&name==$1`, expected: `speed > :limit && name == $1`},
+		{input: `let a=(x in l),b=1 in a&&b>0`, expected: `let a = (x in l), b = 1 in a && b > 0`},
+		{input: `let a=abs(x in l) in a`, expected: `let a = abs(x in l) in a`},
+		{input: `(let a = b in a) && c`, expected: `(let a = b in a) && c`},
+		{input: `c && (let a = b in a)`, expected: `c && (let a = b in a)`},
+	} {
+		ast, err := Parse(test.input)
+		if !assert.NoError(t, err, test.input) {
+			continue
+		}
+		assert.Equal(t, test.expected, Format(ast.Root()), test.input)
+	}
+
+	t.Run("formatted source parses to the same tree", func(t *testing.T) {
+		for _, input := range []string{
+			`a && (b || c) && !(d == (e))`,
+			`(a == b) == c && a == (b == c)`,
+			`(a & b) | c ^ (d << 2) &^ e`,
+			`(x between 1 and 2) == true && x between (1 | 2) and (3 & 4)`,
+			`(a == b) is bool && (x is number) == true`,
+			`(name like 'a%') == (path glob 'b*')`,
+			`x in (1..5) && y in (1 | 2)..<(8 >> 1)`,
+			`(10% of 20%) of total > 1 && 10% of (a | b) > 1`,
+			`let a = (x in [1]), b = (let c = (y in [2]) in c) in a && b && (let d = e in d)`,
+			`!(let a = b in a) || [let c = d in c, (e in f)] == []`,
+		} {
+			ast, err := Parse(input)
+			if !assert.NoError(t, err, input) {
+				continue
+			}
+
+			formatted := Format(ast.Root())
+			reparsed, err := Parse(formatted)
+			if !assert.NoError(t, err, formatted) {
+				continue
+			}
+			assert.Equal(t, shape(ast.Root()), shape(reparsed.Root()), formatted)
+			assert.Equal(t, formatted, Format(reparsed.Root()), "formatting is idempotent")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package boule
+
+import "fmt"
+
+// function is a built-in function that can be called from expressions.
+type function struct {
+	minArguments int
+	maxArguments int // negative for variadic functions
+	returns      Type
+	call         func(arguments []interface{}) (interface{}, error)
+}
+
+var functions = map[string]*function{
+	"semver": {minArguments: 1, maxArguments: 1, returns: TypeVersion, call: callSemver},
+	"time":   {minArguments: 1, maxArguments: 1, returns: TypeTime, call: callTime},
+
+	// strings
+	"lower":    {minArguments: 1, maxArguments: 1, returns: TypeString, call: callLower},
+	"upper":    {minArguments: 1, maxArguments: 1, returns: TypeString, call: callUpper},
+	"trim":     {minArguments: 1, maxArguments: 2, returns: TypeString, call: callTrim},
+	"len":      {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callLen},
+	"substr":   {minArguments: 2, maxArguments: 3, returns: TypeString, call: callSubstr},
+	"replace":  {minArguments: 3, maxArguments: 3, returns: TypeString, call: callReplace},
+	"split":    {minArguments: 2, maxArguments: 2, returns: TypeAny, call: callSplit},
+	"join":     {minArguments: 2, maxArguments: 2, returns: TypeString, call: callJoin},
+	"index_of": {minArguments: 2, maxArguments: 2, returns: TypeNumber, call: callIndexOf},
+	"pad_left": {minArguments: 2, maxArguments: 3, returns: TypeString, call: callPadLeft},
+	"format":   {minArguments: 1, maxArguments: -1, returns: TypeString, call: callFormat},
+
+	// math
+	"abs":   {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callAbs},
+	"min":   {minArguments: 2, maxArguments: -1, returns: TypeNumber, call: callMin},
+	"max":   {minArguments: 2, maxArguments: -1, returns: TypeNumber, call: callMax},
+	"round": {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callRound},
+	"floor": {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callFloor},
+	"ceil":  {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callCeil},
+	"pow":   {minArguments: 2, maxArguments: 2, returns: TypeNumber, call: callPow},
+	"sqrt":  {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callSqrt},
+	"log":   {minArguments: 1, maxArguments: 2, returns: TypeNumber, call: callLog},
+	"clamp": {minArguments: 3, maxArguments: 3, returns: TypeNumber, call: callClamp},
+
+	"approx": {minArguments: 3, maxArguments: 3, returns: TypeBool, call: callApprox},
+
+	// conversions
+	"int":    {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callInt},
+	"float":  {minArguments: 1, maxArguments: 1, returns: TypeNumber, call: callFloat},
+	"string": {minArguments: 1, maxArguments: 1, returns: TypeString, call: callString},
+	"bool":   {minArguments: 1, maxArguments: 1, returns: TypeBool, call: callBool},
+}
+
+// CallExpression represents a call to a built-in function. Calls whose arguments are all
+// literals are evaluated once, when the expression is parsed.
+type CallExpression struct {
+	name          string
+	function      *function
+	arguments     []Node
+	position      int
+	closePosition int
+	constant      bool
+	value         interface{}
+}
+
+// Evaluate calls the function with the evaluated arguments.
+func (c *CallExpression) Evaluate(data *Data) (interface{}, error) {
+
+	if c.constant {
+		return c.value, nil
+	}
+
+	arguments := make([]interface{}, 0, len(c.arguments))
+	for _, argument := range c.arguments {
+		value, err := argument.Evaluate(data)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, value)
+	}
+
+	return c.call(arguments)
+}
+
+// call calls the function with the arguments, and reports its failure as a type error spanning the
+// call.
+func (c *CallExpression) call(arguments []interface{}) (interface{}, error) {
+
+	value, err := c.function.call(arguments)
+	if err != nil {
+		return nil, &TypeError{Msg: fmt.Sprintf("function '%s': %v", c.name, err), Pos: c.Pos(), End: c.End()}
+	}
+
+	return value, nil
+}
+
+// Name returns the name of the called function.
+func (c *CallExpression) Name() string { return c.name }
+
+// Arguments returns the arguments of the call.
+func (c *CallExpression) Arguments() []Node { return c.arguments }
+
+// Pos returns the offset of the function name.
+func (c *CallExpression) Pos() int { return c.position }
+
+// End returns the offset following the closing parenthesis.
+func (c *CallExpression) End() int { return c.closePosition + 1 }
+
+// isConstant reports whether the node evaluates to the same value regardless of the data.
+func isConstant(node Node) bool {
+	switch n := node.(type) {
+	case *LiteralInteger, *LiteralFloat, *LiteralDecimal, *LiteralString, *LiteralIP, *LiteralCIDR:
+		return true
+	case *CallExpression:
+		return n.constant
+	case *ListExpression:
+		for _, element := range n.elements {
+			if !isConstant(element) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -2,66 +2,25 @@ package prefixtree
 
 import (
 	"fmt"
-	"math/big"
 	"reflect"
+	"sort"
 	"strings"
 )
 
-// AddKeyValue adds a single identifier to the prefix tree.
+// Insert adds a single key associated with arbitrary data, such as a value or the declared type of
+// an identifier, to the prefix tree. The data is stored as is: checking that it is supported is up
+// to the caller.
 //
-// Keys must start with an ASCII letter (a-z, A-Z) and may only contain ASCII letters,
-// digits (0-9), underscores, and dots. Reserved keywords "true" and "false" are rejected.
-// Supported value types: bool, string, int, int8, int16, int32, int64, uint, uint8,
-// uint16, uint32, uint64, float32, float64, and *big.Int.
-func (p *Tree) AddKeyValue(key string, value interface{}) error {
-	return p.addKeyValue(key, value)
-}
-
-// AddMap adds all entries from a map[string]interface{} to the prefix tree.
-// Keys and values follow the same rules as AddKeyValue.
-func (p *Tree) AddMap(m map[string]interface{}) error {
-	for k, v := range m {
-		if err := p.addKeyValue(k, v); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// AddStruct adds fields from a struct to the prefix tree. Field names are derived
-// from json struct tags (fields without a json tag are ignored). Nested structs are
-// supported via dot notation (e.g. "owner.name"). Slice and map fields are skipped.
-func (p *Tree) AddStruct(s interface{}) error {
-	fieldMap, err := p.structToJsonFieldMap(s)
-	if err != nil {
+// Keys must start with an ASCII letter (a-z, A-Z) and may only contain ASCII letters, digits
+// (0-9), underscores, and dots. Reserved keywords "true" and "false" are rejected.
+func (p *Tree) Insert(key string, data interface{}) error {
+	if err := validateKey(key); err != nil {
 		return err
 	}
-	for k, v := range fieldMap {
-		if err := p.addKeyValue(k, v); err != nil {
-			return err
-		}
-	}
+	p.add(key, data)
 	return nil
 }
 
-// Deprecated: Add is kept for backward compatibility. Use AddKeyValue, AddMap, or AddStruct instead.
-func (p *Tree) Add(input ...interface{}) error {
-	if len(input) == 1 {
-		if m, ok := input[0].(map[string]interface{}); ok {
-			return p.AddMap(m)
-		}
-		return p.AddStruct(input[0])
-	}
-	if len(input) == 2 {
-		key, ok := input[0].(string)
-		if !ok {
-			return fmt.Errorf("key must be of type string")
-		}
-		return p.AddKeyValue(key, input[1])
-	}
-	return fmt.Errorf("expected 1 or 2 arguments, got %d", len(input))
-}
-
 var reservedKeywords = map[string]struct{}{
 	"true":  {},
 	"false": {},
@@ -97,20 +56,15 @@ func isASCIIDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
-func (p *Tree) addKeyValue(key string, value interface{}) error {
-	if err := validateKey(key); err != nil {
-		return err
-	}
-	switch value.(type) {
-	case bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, *big.Int:
-		p.add(key, value)
-		return nil
-	default:
-		return fmt.Errorf("'value' type %T is not supported", value)
-	}
+// StructValues returns the fields of a struct keyed by their json name, the fields of nested
+// structs in dot notation. Fields whose type is a value type, as reported by isValue, are returned
+// as is instead of being walked into as nested structs, and slices and arrays of values as lists.
+// Map fields, slices of structs, maps or slices, and nil pointer and nil slice fields are skipped.
+func StructValues(input interface{}, isValue func(reflect.Type) bool) (map[string]interface{}, error) {
+	return structToJsonFieldMap(input, isValue)
 }
 
-func (p *Tree) structToJsonFieldMap(input interface{}) (map[string]interface{}, error) {
+func structToJsonFieldMap(input interface{}, isValue func(reflect.Type) bool) (map[string]interface{}, error) {
 
 	fieldMap := make(map[string]interface{})
 
@@ -130,22 +84,34 @@ func (p *Tree) structToJsonFieldMap(input interface{}) (map[string]interface{},
 
 		structField = inputType.Field(i)
 
-		jsonName, ok = structField.Tag.Lookup("json")
+		jsonName, _, ok = jsonTag(structField)
 		if !ok {
 			continue // ignore field without 'json' tag name
 		}
-		if split := strings.Split(jsonName, ","); len(split) > 1 {
-			jsonName = split[0]
-		}
 
 		fieldType, fieldValue = structField.Type, inputValue.Field(i)
 
+		if isValue(fieldType) { // struct, slice or pointer types supported as values
+			if (fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice) && fieldValue.IsNil() {
+				continue // nil pointers and slices are missing fields
+			}
+			fieldMap[jsonName] = fieldValue.Interface()
+			continue
+		}
+
 		if fieldType.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				continue // nil pointers are missing fields
+			}
 			fieldType, fieldValue = fieldType.Elem(), fieldValue.Elem()
+			if isValue(fieldType) {
+				fieldMap[jsonName] = fieldValue.Interface()
+				continue
+			}
 		}
 
 		if fieldType.Kind() == reflect.Struct { // recurse on struct field
-			subFieldMap, err := p.structToJsonFieldMap(fieldValue.Interface())
+			subFieldMap, err := structToJsonFieldMap(fieldValue.Interface(), isValue)
 			if err != nil {
 				return nil, err
 			}
@@ -155,8 +121,11 @@ func (p *Tree) structToJsonFieldMap(input interface{}) (map[string]interface{},
 			continue
 		}
 
-		if fieldType.Kind() == reflect.Slice {
-			continue // slice fields are not supported for now
+		if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+			if listable(fieldType, isValue) && !(fieldType.Kind() == reflect.Slice && fieldValue.IsNil()) {
+				fieldMap[jsonName] = listOf(fieldValue)
+			}
+			continue // nil slices are missing fields
 		}
 
 		if fieldType.Kind() == reflect.Map {
@@ -168,3 +137,123 @@ func (p *Tree) structToJsonFieldMap(input interface{}) (map[string]interface{},
 
 	return fieldMap, nil
 }
+
+// listable reports whether the elements of a slice or array type are values, or interfaces that
+// may hold values, which the slice is stored as a list of. Slices of structs, maps or slices are not.
+func listable(t reflect.Type, isValue func(reflect.Type) bool) bool {
+	return isValue(t.Elem()) || t.Elem().Kind() == reflect.Interface
+}
+
+// listOf returns the elements of a slice or array value as a list.
+func listOf(v reflect.Value) []interface{} {
+	list := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		list = append(list, v.Index(i).Interface())
+	}
+	return list
+}
+
+// jsonTag returns the name of a struct field in its json tag, and whether the tag has the
+// omitempty option. Fields without json tag, or tagged "-", have no name.
+func jsonTag(field reflect.StructField) (name string, omitempty bool, ok bool) {
+
+	tag, ok := field.Tag.Lookup("json")
+	if !ok || tag == "-" {
+		return "", false, false
+	}
+
+	split := strings.Split(tag, ",")
+	for _, option := range split[1:] {
+		omitempty = omitempty || option == "omitempty"
+	}
+
+	return split[0], omitempty, true
+}
+
+// StructField is a field of a struct type, named like StructValues names it.
+type StructField struct {
+	Key      string       // json name, prefixed with the names of the enclosing structs in dot notation
+	Type     reflect.Type // type of the field, dereferenced unless it is a supported value type
+	Optional bool         // whether the field may be missing: a pointer, omitempty, a slice, or in an optional struct
+}
+
+// StructFields walks a struct type the way StructValues walks a struct value, and returns its fields
+// sorted by key. Pointers are dereferenced, and the fields of nested structs are flattened in dot
+// notation, except for the value types reported by isValue. Slices are optional, since StructValues
+// skips nil slices, and slices that aren't listable are left out, as StructValues leaves them out.
+func StructFields(t reflect.Type, isValue func(reflect.Type) bool) ([]StructField, error) {
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %s is not a struct", t)
+	}
+
+	fields, err := structFields(t, "", false, isValue, make(map[reflect.Type]struct{}))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+
+	return fields, nil
+}
+
+// structFields returns the fields of a struct type, the types of its enclosing structs being
+// visited to reject recursive types, which can't be flattened.
+func structFields(t reflect.Type, prefix string, optional bool, isValue func(reflect.Type) bool, visited map[reflect.Type]struct{}) ([]StructField, error) {
+
+	if _, ok := visited[t]; ok {
+		return nil, fmt.Errorf("type %s is recursive", t)
+	}
+	visited[t] = struct{}{}
+	defer delete(visited, t)
+
+	var fields []StructField
+
+	for i := 0; i < t.NumField(); i++ {
+
+		structField := t.Field(i)
+
+		jsonName, omitempty, ok := jsonTag(structField)
+		if !ok {
+			continue // ignore field without 'json' tag name
+		}
+
+		field := StructField{Key: prefix + jsonName, Type: structField.Type, Optional: optional || omitempty}
+
+		if field.Type.Kind() == reflect.Ptr {
+			field.Optional = true
+			if !isValue(field.Type) {
+				field.Type = field.Type.Elem()
+			}
+		}
+
+		if isValue(field.Type) { // struct, slice or pointer types supported as values
+			fields = append(fields, field)
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct: // recurse on struct field
+			subFields, err := structFields(field.Type, field.Key+".", field.Optional, isValue, visited)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, subFields...)
+		case reflect.Slice, reflect.Array:
+			if !listable(field.Type, isValue) {
+				continue // slices of structs, maps or slices are not supported for now
+			}
+			field.Optional = field.Optional || field.Type.Kind() == reflect.Slice
+			fields = append(fields, field)
+		case reflect.Map, reflect.Chan, reflect.Func:
+			continue // map fields are not supported for now
+		default:
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
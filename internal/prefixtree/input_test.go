@@ -2,71 +2,30 @@ package prefixtree
 
 import (
 	"github.com/stretchr/testify/assert"
+	"math/big"
+	"reflect"
 	"testing"
+	"time"
 )
 
-func TestTree_AddKeyValue(t *testing.T) {
+func TestTree_Insert(t *testing.T) {
 
-	t.Run("can add key/value pair", func(t *testing.T) {
+	t.Run("stores data as is", func(t *testing.T) {
 		tree := new(Tree)
-		assert.NoError(t, tree.AddKeyValue("some_key", 380))
-		value, err := tree.Find("some_key")
+		assert.NoError(t, tree.Insert("some_key", []int{1, 2}))
+		value, err := tree.Find("some")
 		if assert.NoError(t, err) {
-			if assert.IsType(t, 10, value) {
-				assert.Equal(t, 380, value)
-			}
+			assert.Equal(t, []int{1, 2}, value)
 		}
 	})
 
-	t.Run("rejects unsupported value type", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("key", []int{1, 2}))
+	t.Run("rejects invalid key", func(t *testing.T) {
+		assert.Error(t, new(Tree).Insert("1key", 1))
+		assert.Error(t, new(Tree).Insert("true", 1))
 	})
 }
 
-func TestTree_AddMap(t *testing.T) {
-
-	t.Run("can add map as data", func(t *testing.T) {
-		tree := new(Tree)
-		assert.NoError(t, tree.AddMap(map[string]interface{}{
-			"road":   "Wellington",
-			"number": 20,
-		}))
-
-		value, err := tree.Find("road")
-		if assert.NoError(t, err) {
-			if assert.IsType(t, "string", value) {
-				assert.Equal(t, "Wellington", value)
-			}
-		}
-
-		value, err = tree.Find("number")
-		if assert.NoError(t, err) {
-			if assert.IsType(t, 0, value) {
-				assert.Equal(t, 20, value)
-			}
-		}
-	})
-
-	t.Run("map can't index slice", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddMap(map[string]interface{}{
-			"index": []int{0, 1, 2},
-		}))
-	})
-
-	t.Run("map can't index map", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddMap(map[string]interface{}{
-			"index": map[string]int{"un": 1, "deux": 2},
-		}))
-	})
-
-	t.Run("rejects reserved keyword in map", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddMap(map[string]interface{}{
-			"true": 1,
-		}))
-	})
-}
-
-func TestTree_AddStruct(t *testing.T) {
+func TestStructValues(t *testing.T) {
 
 	t.Run("can add struct as data", func(t *testing.T) {
 		tree := new(Tree)
@@ -78,7 +37,7 @@ func TestTree_AddStruct(t *testing.T) {
 			Number: 20,
 		}
 
-		assert.NoError(t, tree.AddStruct(data))
+		assert.NoError(t, addStruct(tree, data))
 
 		value, err := tree.Find("road")
 		if assert.NoError(t, err) {
@@ -105,7 +64,7 @@ func TestTree_AddStruct(t *testing.T) {
 			Number: 20,
 		}
 
-		assert.NoError(t, tree.AddStruct(data))
+		assert.NoError(t, addStruct(tree, data))
 
 		value, err := tree.Find("road")
 		if assert.NoError(t, err) {
@@ -134,7 +93,7 @@ func TestTree_AddStruct(t *testing.T) {
 			},
 		}
 
-		assert.NoError(t, tree.AddStruct(data))
+		assert.NoError(t, addStruct(tree, data))
 
 		value, err := tree.Find("road")
 		if assert.NoError(t, err) {
@@ -153,7 +112,7 @@ func TestTree_AddStruct(t *testing.T) {
 
 	t.Run("embedded maps are not supported", func(t *testing.T) {
 		tree := new(Tree)
-		assert.NoError(t, tree.AddStruct(struct {
+		assert.NoError(t, addStruct(tree, struct {
 			Index map[string]int `json:"index"`
 		}{
 			Index: map[string]int{"un": 1, "deux": 2, "trois": 3},
@@ -163,96 +122,208 @@ func TestTree_AddStruct(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("embedded slice are not supported", func(t *testing.T) {
+	t.Run("slices of values are added as lists", func(t *testing.T) {
+		type Owner struct {
+			Name string `json:"name"`
+		}
+
 		tree := new(Tree)
-		assert.NoError(t, tree.AddStruct(struct {
-			Index []int `json:"index"`
+		assert.NoError(t, addStruct(tree, struct {
+			Index  []int      `json:"index"`
+			Codes  [2]string  `json:"codes"`
+			Tags   []string   `json:"tags"`
+			Owners []Owner    `json:"owners"`
+			Matrix [][]int    `json:"matrix"`
+			Sizes  []*big.Int `json:"sizes"`
 		}{
-			Index: []int{1, 2, 3},
+			Index:  []int{1, 2, 3},
+			Codes:  [2]string{"a", "b"},
+			Owners: []Owner{{Name: "Ada"}},
+			Matrix: [][]int{{1}},
+			Sizes:  []*big.Int{big.NewInt(1)},
 		}))
 
-		_, err := tree.Find("index")
+		value, err := tree.Find("index")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []interface{}{1, 2, 3}, value)
+		}
+		value, err = tree.Find("codes")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []interface{}{"a", "b"}, value)
+		}
+		value, err = tree.Find("sizes")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []interface{}{big.NewInt(1)}, value)
+		}
+
+		for _, key := range []string{"tags", "owners", "matrix"} {
+			_, err = tree.Find(key)
+			assert.Error(t, err, key) // nil slices, and slices of structs or slices, are skipped
+		}
+	})
+
+	t.Run("nil pointer fields are skipped", func(t *testing.T) {
+		type Owner struct {
+			Name string `json:"name"`
+		}
+
+		created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		tree := new(Tree)
+		assert.NoError(t, addStruct(tree, struct {
+			Owner   *Owner     `json:"owner"`
+			Created *time.Time `json:"created"`
+			Deleted *time.Time `json:"deleted"`
+			Price   *big.Rat   `json:"price"`
+		}{
+			Created: &created,
+		}))
+
+		_, err := tree.Find("owner.name")
 		assert.Error(t, err)
+		_, err = tree.Find("deleted")
+		assert.Error(t, err)
+		_, err = tree.Find("price")
+		assert.Error(t, err)
+
+		value, err := tree.Find("created")
+		if assert.NoError(t, err) {
+			assert.Equal(t, created, value)
+		}
 	})
 
 	t.Run("rejects non-struct input", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddStruct("not a struct"))
+		assert.Error(t, addStruct(new(Tree), "not a struct"))
+	})
+}
+
+func TestStructFields(t *testing.T) {
+
+	type Owner struct {
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+	}
+
+	t.Run("flattens nested structs", func(t *testing.T) {
+		fields, err := StructFields(reflect.TypeOf(struct {
+			Road    string         `json:"road"`
+			Owner   Owner          `json:"owner"`
+			Tenant  *Owner         `json:"tenant"`
+			Tags    []string       `json:"tags"`
+			Codes   [2]string      `json:"codes"`
+			Owners  []Owner        `json:"owners"`
+			Index   map[string]int `json:"index"`
+			Created *time.Time     `json:"created"`
+			Ignored string         `json:"-"`
+			Number  int
+		}{}), isValueType)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []StructField{
+				{Key: "codes", Type: reflect.TypeOf([2]string{})},
+				{Key: "created", Type: reflect.TypeOf(time.Time{}), Optional: true},
+				{Key: "owner.email", Type: reflect.TypeOf(""), Optional: true},
+				{Key: "owner.name", Type: reflect.TypeOf("")},
+				{Key: "road", Type: reflect.TypeOf("")},
+				{Key: "tags", Type: reflect.TypeOf([]string{}), Optional: true},
+				{Key: "tenant.email", Type: reflect.TypeOf(""), Optional: true},
+				{Key: "tenant.name", Type: reflect.TypeOf(""), Optional: true},
+			}, fields)
+		}
+	})
+
+	t.Run("keeps pointer value types", func(t *testing.T) {
+		fields, err := StructFields(reflect.TypeOf(struct {
+			Amount *big.Rat `json:"amount"`
+		}{}), isValueType)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []StructField{{Key: "amount", Type: reflect.TypeOf((*big.Rat)(nil)), Optional: true}}, fields)
+		}
+	})
+
+	t.Run("rejects non-struct and recursive types", func(t *testing.T) {
+		type Node struct {
+			Next *Node `json:"next"`
+		}
+
+		_, err := StructFields(reflect.TypeOf(1), isValueType)
+		assert.Error(t, err)
+		_, err = StructFields(reflect.TypeOf(Node{}), isValueType)
+		assert.Error(t, err)
 	})
 }
 
 func TestTree_KeyValidation(t *testing.T) {
 
 	t.Run("rejects reserved keyword 'true'", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("true", 1))
+		assert.Error(t, new(Tree).Insert("true", 1))
 	})
 
 	t.Run("rejects reserved keyword 'false'", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("false", 1))
+		assert.Error(t, new(Tree).Insert("false", 1))
+	})
+
+	t.Run("accepts operator keywords", func(t *testing.T) {
+		for _, key := range []string{"let", "in", "between", "and", "like", "glob", "is", "of"} {
+			assert.NoError(t, new(Tree).Insert(key, 1), key)
+		}
 	})
 
 	t.Run("rejects empty key", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("", 1))
+		assert.Error(t, new(Tree).Insert("", 1))
 	})
 
 	t.Run("rejects key starting with digit", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("1abc", 1))
+		assert.Error(t, new(Tree).Insert("1abc", 1))
 	})
 
 	t.Run("rejects key containing operator characters", func(t *testing.T) {
 		for _, key := range []string{"a==b", "a>b", "a<b", "a!", "a&b", "a|b"} {
-			assert.Error(t, new(Tree).AddKeyValue(key, 1), "expected error for key %q", key)
+			assert.Error(t, new(Tree).Insert(key, 1), "expected error for key %q", key)
 		}
 	})
 
 	t.Run("rejects key containing parentheses", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("foo(bar)", 1))
+		assert.Error(t, new(Tree).Insert("foo(bar)", 1))
 	})
 
 	t.Run("rejects key containing spaces", func(t *testing.T) {
-		assert.Error(t, new(Tree).AddKeyValue("foo bar", 1))
+		assert.Error(t, new(Tree).Insert("foo bar", 1))
 	})
 
 	t.Run("accepts valid identifier with dots and underscores", func(t *testing.T) {
-		assert.NoError(t, new(Tree).AddKeyValue("ship.max_speed", 100))
+		assert.NoError(t, new(Tree).Insert("ship.max_speed", 100))
 	})
 }
 
-func TestTree_AddBackwardCompat(t *testing.T) {
-
-	t.Run("key/value via Add", func(t *testing.T) {
-		tree := new(Tree)
-		assert.NoError(t, tree.Add("some_key", 380))
-		value, err := tree.Find("some_key")
-		if assert.NoError(t, err) {
-			assert.Equal(t, 380, value)
-		}
-	})
-
-	t.Run("map via Add", func(t *testing.T) {
-		tree := new(Tree)
-		assert.NoError(t, tree.Add(map[string]interface{}{"road": "Wellington"}))
-		value, err := tree.Find("road")
-		if assert.NoError(t, err) {
-			assert.Equal(t, "Wellington", value)
-		}
-	})
-
-	t.Run("struct via Add", func(t *testing.T) {
-		tree := new(Tree)
-		assert.NoError(t, tree.Add(struct {
-			Road string `json:"road"`
-		}{Road: "Wellington"}))
-		value, err := tree.Find("road")
-		if assert.NoError(t, err) {
-			assert.Equal(t, "Wellington", value)
+// addStruct inserts the values of a struct in the tree, the way the callers of StructValues do.
+func addStruct(tree *Tree, s interface{}) error {
+	values, err := StructValues(s, isValueType)
+	if err != nil {
+		return err
+	}
+	for k, v := range values {
+		if err := tree.Insert(k, v); err != nil {
+			return err
 		}
-	})
-
-	t.Run("wrong arg count via Add", func(t *testing.T) {
-		assert.Error(t, new(Tree).Add("a", 1, "extra"))
-	})
+	}
+	return nil
+}
 
-	t.Run("non-string key via Add", func(t *testing.T) {
-		assert.Error(t, new(Tree).Add(3, 380))
-	})
+// isValueType stands for the value types of the callers: big numbers, times, and the kinds of the
+// predeclared types.
+func isValueType(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf((*big.Int)(nil)), reflect.TypeOf((*big.Rat)(nil)), reflect.TypeOf(time.Time{}):
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,183 @@
+// Package semver implements parsing and precedence of semantic versions, as specified by
+// Semantic Versioning 2.0.0 (https://semver.org).
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version. Build metadata is kept but ignored when comparing versions.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses a semantic version of the form MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD].
+// A leading 'v' is accepted.
+func Parse(s string) (Version, error) {
+
+	var v Version
+	input := s
+
+	s = strings.TrimPrefix(s, "v")
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		build, err := identifiers(s[i+1:], false)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version %q: build metadata: %w", input, err)
+		}
+		v.Build, s = build, s[:i]
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		prerelease, err := identifiers(s[i+1:], true)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version %q: pre-release: %w", input, err)
+		}
+		v.Prerelease, s = prerelease, s[:i]
+	}
+
+	core := strings.Split(s, ".")
+	if len(core) != 3 {
+		return Version{}, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", input)
+	}
+
+	for i, target := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if !isNumeric(core[i]) || (len(core[i]) > 1 && core[i][0] == '0') {
+			return Version{}, fmt.Errorf("invalid semantic version %q: malformed number %q", input, core[i])
+		}
+		number, err := strconv.ParseUint(core[i], 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version %q: %w", input, err)
+		}
+		*target = number
+	}
+
+	return v, nil
+}
+
+// identifiers splits and validates dot-separated pre-release or build identifiers.
+func identifiers(s string, prerelease bool) ([]string, error) {
+
+	split := strings.Split(s, ".")
+
+	for _, identifier := range split {
+		if identifier == "" {
+			return nil, fmt.Errorf("empty identifier")
+		}
+		for i := 0; i < len(identifier); i++ {
+			c := identifier[i]
+			if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != '-' {
+				return nil, fmt.Errorf("invalid character %q in identifier %q", c, identifier)
+			}
+		}
+		if prerelease && isNumeric(identifier) && len(identifier) > 1 && identifier[0] == '0' {
+			return nil, fmt.Errorf("numeric identifier %q has a leading zero", identifier)
+		}
+	}
+
+	return split, nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare returns -1, 0 or +1 depending on whether v has a lower, equal or higher precedence
+// than other.
+func (v Version) Compare(other Version) int {
+
+	for _, pair := range [][2]uint64{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A version without pre-release has a higher precedence than one with pre-release.
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) < len(other.Prerelease):
+		return -1
+	case len(v.Prerelease) > len(other.Prerelease):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareIdentifier compares pre-release identifiers: numeric identifiers are compared
+// numerically and have a lower precedence than alphanumeric ones, compared in ASCII order.
+func compareIdentifier(a, b string) int {
+
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) { // no leading zeros, so the longer number is the greater
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// String returns the canonical form of the version.
+func (v Version) String() string {
+
+	var b strings.Builder
+
+	b.WriteString(strconv.FormatUint(v.Major, 10))
+	b.WriteByte('.')
+	b.WriteString(strconv.FormatUint(v.Minor, 10))
+	b.WriteByte('.')
+	b.WriteString(strconv.FormatUint(v.Patch, 10))
+
+	if len(v.Prerelease) > 0 {
+		b.WriteByte('-')
+		b.WriteString(strings.Join(v.Prerelease, "."))
+	}
+	if len(v.Build) > 0 {
+		b.WriteByte('+')
+		b.WriteString(strings.Join(v.Build, "."))
+	}
+
+	return b.String()
+}
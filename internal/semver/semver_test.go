@@ -0,0 +1,83 @@
+package semver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	t.Run("parses full version", func(t *testing.T) {
+		v, err := Parse("1.4.2-rc.1+build.5")
+		if assert.NoError(t, err) {
+			assert.Equal(t, Version{
+				Major:      1,
+				Minor:      4,
+				Patch:      2,
+				Prerelease: []string{"rc", "1"},
+				Build:      []string{"build", "5"},
+			}, v)
+			assert.Equal(t, "1.4.2-rc.1+build.5", v.String())
+		}
+	})
+
+	t.Run("accepts leading v", func(t *testing.T) {
+		v, err := Parse("v2.3.0")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "2.3.0", v.String())
+		}
+	})
+
+	t.Run("rejects malformed versions", func(t *testing.T) {
+		for _, input := range []string{
+			"", "1", "1.2", "1.2.3.4", "01.2.3", "1.2.x", "1.2.3-", "1.2.3-01", "1.2.3-alpha..1", "1.2.3+", "1.2.3-rc_1",
+		} {
+			_, err := Parse(input)
+			assert.Error(t, err, input)
+		}
+	})
+}
+
+func TestVersion_Compare(t *testing.T) {
+
+	t.Run("follows precedence rules", func(t *testing.T) {
+		ordered := []string{
+			"1.0.0-0",
+			"1.0.0-alpha",
+			"1.0.0-alpha.1",
+			"1.0.0-alpha.beta",
+			"1.0.0-beta",
+			"1.0.0-beta.2",
+			"1.0.0-beta.11",
+			"1.0.0-rc.1",
+			"1.0.0",
+			"1.0.1",
+			"1.2.0",
+			"1.10.0",
+			"2.0.0",
+		}
+
+		for i := range ordered {
+			for j := range ordered {
+				vi, err := Parse(ordered[i])
+				assert.NoError(t, err)
+				vj, err := Parse(ordered[j])
+				assert.NoError(t, err)
+
+				expected := 0
+				if i < j {
+					expected = -1
+				} else if i > j {
+					expected = 1
+				}
+				assert.Equal(t, expected, vi.Compare(vj), "%s <=> %s", ordered[i], ordered[j])
+			}
+		}
+	})
+
+	t.Run("ignores build metadata", func(t *testing.T) {
+		a, _ := Parse("1.0.0+build.1")
+		b, _ := Parse("1.0.0+build.2")
+		assert.Equal(t, 0, a.Compare(b))
+	})
+}
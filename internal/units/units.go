@@ -0,0 +1,86 @@
+// Package units implements the units of numeric literals: byte sizes, with decimal (KB, MB, ...)
+// and binary (KiB, MiB, ...) multiples, and percentages.
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ByteSize is a number of bytes.
+type ByteSize uint64
+
+// Percent is a percentage, e.g. Percent(20) is 20%, which compares as the fraction 0.2.
+type Percent float64
+
+// Fraction returns the percentage as a fraction, e.g. 0.2 for 20%.
+func (p Percent) Fraction() float64 {
+	return float64(p) / 100
+}
+
+// factors of the units, by suffix.
+var factors = map[string]*big.Rat{
+	"%":   big.NewRat(1, 100),
+	"B":   big.NewRat(1, 1),
+	"kB":  power(1000, 1),
+	"KB":  power(1000, 1),
+	"MB":  power(1000, 2),
+	"GB":  power(1000, 3),
+	"TB":  power(1000, 4),
+	"PB":  power(1000, 5),
+	"EB":  power(1000, 6),
+	"KiB": power(1024, 1),
+	"MiB": power(1024, 2),
+	"GiB": power(1024, 3),
+	"TiB": power(1024, 4),
+	"PiB": power(1024, 5),
+	"EiB": power(1024, 6),
+}
+
+func power(base, exponent int64) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(base), big.NewInt(exponent), nil))
+}
+
+// Split splits a literal such as "512MB" into its number and the factor of its unit. It reports
+// false if the literal doesn't end with a known unit directly following a digit.
+func Split(literal string) (string, *big.Rat, bool) {
+	for _, length := range []int{3, 2, 1} {
+		if len(literal) <= length {
+			continue
+		}
+		number, unit := literal[:len(literal)-length], literal[len(literal)-length:]
+		if last := number[len(number)-1]; last < '0' || last > '9' {
+			continue
+		}
+		if factor, ok := factors[unit]; ok {
+			return number, factor, true
+		}
+	}
+	return "", nil, false
+}
+
+// ParseByteSize parses a byte size such as "512MB", "1.5GiB" or "2048" (bytes).
+func ParseByteSize(s string) (ByteSize, error) {
+
+	s = strings.TrimSpace(s)
+
+	number, factor, ok := Split(s)
+	if !ok {
+		number, factor = s, big.NewRat(1, 1)
+	} else if factor.Cmp(factors["%"]) == 0 {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	size, ok := new(big.Rat).SetString(number)
+	if !ok || size.Sign() < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	size.Mul(size, factor)
+	if !size.IsInt() || !size.Num().IsUint64() {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	return ByteSize(size.Num().Uint64()), nil
+}
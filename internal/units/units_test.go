@@ -0,0 +1,58 @@
+package units
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+
+	for _, test := range []struct {
+		literal string
+		number  string
+		factor  *big.Rat
+		ok      bool
+	}{
+		{"512MB", "512", big.NewRat(1000000, 1), true},
+		{"1.5GiB", "1.5", big.NewRat(1<<30, 1), true},
+		{"20%", "20", big.NewRat(1, 100), true},
+		{"64B", "64", big.NewRat(1, 1), true},
+		{"8kB", "8", big.NewRat(1000, 1), true},
+		{"512", "", nil, false},
+		{"MB", "", nil, false},
+		{"12XB", "", nil, false},
+	} {
+		number, factor, ok := Split(test.literal)
+		assert.Equal(t, test.ok, ok, test.literal)
+		assert.Equal(t, test.number, number, test.literal)
+		if test.ok {
+			assert.Equal(t, 0, test.factor.Cmp(factor), test.literal)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+
+	for input, expected := range map[string]ByteSize{
+		"512MB":   512000000,
+		"1.5GiB":  1610612736,
+		"2048":    2048,
+		" 1KiB ":  1024,
+		"0.5KB":   500,
+		"16EiB":   0, // out of range
+		"1.5B":    0, // not a whole number of bytes
+		"-1KB":    0,
+		"20%":     0,
+		"lots":    0,
+		"1.5 GiB": 0,
+	} {
+		size, err := ParseByteSize(input)
+		if expected == 0 {
+			assert.Error(t, err, input)
+			continue
+		}
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, size, input)
+	}
+}
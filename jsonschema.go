@@ -0,0 +1,382 @@
+package boule
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the documents a Schema is exported to.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of a JSON Schema document describing identifiers: objects with their
+// properties, types, formats, alternatives and enumerated values. Other keywords are ignored.
+type jsonSchema struct {
+	Dialect    string                 `json:"$schema,omitempty"`
+	Type       jsonTypes              `json:"type,omitempty"`
+	Format     string                 `json:"format,omitempty"`
+	AnyOf      []*jsonSchema          `json:"anyOf,omitempty"`
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Enum       jsonEnum               `json:"enum,omitempty"`
+}
+
+// jsonTypes is the value of the type keyword, either a single type or a list of types.
+type jsonTypes []string
+
+func (t jsonTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *jsonTypes) UnmarshalJSON(data []byte) error {
+
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = jsonTypes{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+
+	*t = list
+	return nil
+}
+
+// jsonEnum is the value of the enum keyword. Numbers are decoded exactly, as int64, *big.Int or
+// *big.Rat values, rather than as float64.
+type jsonEnum []interface{}
+
+func (e jsonEnum) MarshalJSON() ([]byte, error) {
+
+	values := make([]interface{}, 0, len(e))
+	for _, value := range e {
+		switch v := value.(type) {
+		case *big.Rat:
+			if s := formatRat(v); !strings.Contains(s, "/") {
+				value = json.Number(s)
+			} else {
+				value, _ = v.Float64()
+			}
+		case Version:
+			value = v.String()
+		}
+		values = append(values, value)
+	}
+
+	return json.Marshal(values)
+}
+
+func (e *jsonEnum) UnmarshalJSON(data []byte) error {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var values []interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return err
+	}
+
+	for i, value := range values {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		rat, ok := parseDecimal(number.String())
+		if !ok {
+			return fmt.Errorf("invalid number %s", number)
+		}
+		switch {
+		case !rat.IsInt():
+			values[i] = rat
+		case rat.Num().IsInt64():
+			values[i] = rat.Num().Int64()
+		default:
+			values[i] = rat.Num()
+		}
+	}
+
+	*e = values
+	return nil
+}
+
+// contains reports whether the name is among the names.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFormats are the formats of strings read as other types. The formats of versions and networks
+// are not defined by JSON Schema, whose validators ignore them.
+var jsonFormats = map[string]Type{
+	"date-time": TypeTime,
+	"date":      TypeTime,
+	"ipv4":      TypeIP,
+	"ipv6":      TypeIP,
+	"cidr":      TypeNetwork,
+	"semver":    TypeVersion,
+}
+
+// MarshalJSON exports the schema as a JSON Schema document describing an object, whose properties
+// are the identifiers, the identifiers in dot notation being the properties of nested objects. Times
+// are strings of format date-time, IP addresses are strings of any of the formats ipv4 and ipv6,
+// and versions and networks, which have no standard format, are strings of the formats semver and
+// cidr.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+
+	document := &jsonSchema{Dialect: jsonSchemaDialect, Type: jsonTypes{"object"}}
+
+	for _, field := range s.Fields() {
+		if err := document.export(field, field.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(document)
+}
+
+// UnmarshalJSON imports the schema from a JSON Schema document describing an object. Its
+// properties are declared as identifiers, required if the object requires them and if they can't
+// be null, and the properties of nested objects are declared in dot notation, e.g. "owner.name".
+// Strings of formats date-time and date are times, strings of formats ipv4 and ipv6 are IP
+// addresses, strings of format cidr are networks, and strings of format semver are versions. A
+// property is of the type shared by all of its anyOf alternatives, e.g. any of ipv4 and ipv6.
+// Enumerated values restrict the values of an identifier, whose type is inferred from them when
+// the property has no type.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+
+	var document jsonSchema
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	if !document.object() {
+		return fmt.Errorf("JSON Schema must describe an object")
+	}
+
+	schema := NewSchema()
+	if err := schema.load(&document, "", true); err != nil {
+		return err
+	}
+
+	*s = *schema
+	return nil
+}
+
+// object reports whether the JSON Schema describes an object.
+func (j *jsonSchema) object() bool {
+	return contains(j.Type, "object") || (len(j.Type) == 0 && j.Properties != nil)
+}
+
+// load declares the properties of an object, prefixed with the names of the enclosing objects.
+func (s *Schema) load(object *jsonSchema, prefix string, required bool) error {
+
+	names := make([]string, 0, len(object.Properties))
+	for name := range object.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		property := object.Properties[name]
+		if property == nil {
+			return fmt.Errorf("property '%s%s' must be an object", prefix, name)
+		}
+
+		field := Field{
+			Name:     prefix + name,
+			Required: required && contains(object.Required, name) && !contains(property.Type, "null"),
+			Enum:     property.Enum,
+		}
+
+		if property.object() && len(property.Properties) > 0 {
+			if err := s.load(property, field.Name+".", field.Required); err != nil {
+				return err
+			}
+			continue
+		}
+
+		t, err := property.valueType()
+		if err != nil {
+			return fmt.Errorf("property '%s': %w", field.Name, err)
+		}
+		field.Type = t
+
+		if err = s.add(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// valueType returns the type of the values described by a JSON Schema. Types that aren't values
+// of Data, such as objects without properties, and lists of several types other than null, are
+// TypeAny.
+func (j *jsonSchema) valueType() (Type, error) {
+
+	if len(j.AnyOf) > 0 {
+		return j.alternativeType()
+	}
+
+	var types []string
+	for _, t := range j.Type {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+
+	switch len(types) {
+	case 0:
+		return enumType(j.Enum), nil
+	case 1:
+	default:
+		return TypeAny, nil
+	}
+
+	switch types[0] {
+	case "boolean":
+		return TypeBool, nil
+	case "integer", "number":
+		return TypeNumber, nil
+	case "array":
+		return TypeList, nil
+	case "object":
+		return TypeAny, nil
+	case "string":
+		if t, ok := jsonFormats[j.Format]; ok {
+			return t, nil
+		}
+		return TypeString, nil
+	}
+
+	return TypeAny, fmt.Errorf("unknown type '%s'", types[0])
+}
+
+// alternativeType returns the type shared by the anyOf alternatives of a JSON Schema, TypeAny if
+// they have none in common. The alternatives inherit the type, format and enumerated values of the
+// schema they don't redefine.
+func (j *jsonSchema) alternativeType() (Type, error) {
+
+	t := TypeAny
+	for i, alternative := range j.AnyOf {
+
+		if alternative == nil {
+			return TypeAny, fmt.Errorf("anyOf alternatives must be objects")
+		}
+
+		inherited := *alternative
+		if len(inherited.Type) == 0 {
+			inherited.Type = j.Type
+		}
+		if inherited.Format == "" {
+			inherited.Format = j.Format
+		}
+		if inherited.Enum == nil {
+			inherited.Enum = j.Enum
+		}
+
+		a, err := inherited.valueType()
+		if err != nil {
+			return TypeAny, err
+		}
+		if i > 0 && a != t {
+			return TypeAny, nil
+		}
+		t = a
+	}
+
+	return t, nil
+}
+
+// enumType returns the type shared by enumerated values, TypeAny if they have none in common. Null
+// values belong to any type.
+func enumType(enum []interface{}) Type {
+
+	t := TypeAny
+	for _, value := range enum {
+		if value == nil {
+			continue
+		}
+		v := typeOfValue(value)
+		if t != TypeAny && v != t {
+			return TypeAny
+		}
+		t = v
+	}
+
+	return t
+}
+
+// export adds a field to the properties of an object, under its name relative to the object.
+func (j *jsonSchema) export(field Field, name string) error {
+
+	if j.Properties == nil {
+		j.Properties = make(map[string]*jsonSchema)
+	}
+
+	head, tail, nested := strings.Cut(name, ".")
+
+	property, ok := j.Properties[head]
+	switch {
+	case !nested && ok, nested && ok && !property.object():
+		return fmt.Errorf("identifier '%s' is declared both as a value and as an object", field.Name)
+	case nested && !ok:
+		property = &jsonSchema{Type: jsonTypes{"object"}}
+		j.Properties[head] = property
+	}
+
+	if nested {
+		if err := property.export(field, tail); err != nil {
+			return err
+		}
+	} else {
+		j.Properties[head] = exportType(field)
+	}
+
+	if field.Required && !contains(j.Required, head) {
+		j.Required = append(j.Required, head)
+	}
+
+	return nil
+}
+
+// exportType returns the JSON Schema describing the values of a field.
+func exportType(field Field) *jsonSchema {
+
+	property := &jsonSchema{Enum: field.Enum}
+
+	switch field.Type {
+	case TypeBool:
+		property.Type = jsonTypes{"boolean"}
+	case TypeString:
+		property.Type = jsonTypes{"string"}
+	case TypeNumber:
+		property.Type = jsonTypes{"number"}
+	case TypeList:
+		property.Type = jsonTypes{"array"}
+	case TypeTime:
+		property.Type, property.Format = jsonTypes{"string"}, "date-time"
+	case TypeIP:
+		property.Type, property.AnyOf = jsonTypes{"string"}, []*jsonSchema{{Format: "ipv4"}, {Format: "ipv6"}}
+	case TypeNetwork:
+		property.Type, property.Format = jsonTypes{"string"}, "cidr"
+	case TypeVersion:
+		property.Type, property.Format = jsonTypes{"string"}, "semver"
+	}
+
+	return property
+}
@@ -0,0 +1,171 @@
+package boule
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestSchema_JSON(t *testing.T) {
+
+	t.Run("imports JSON Schema documents", func(t *testing.T) {
+		var schema Schema
+		err := json.Unmarshal([]byte(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"destination": {"type": "string", "enum": ["Mars", "Venus"]},
+				"speed": {"type": "integer"},
+				"active": {"type": ["boolean", "null"]},
+				"launch": {"type": "string", "format": "date-time"},
+				"address": {"type": "string", "format": "ipv4"},
+				"gateway": {"type": "string", "anyOf": [{"format": "ipv4"}, {"format": "ipv6"}]},
+				"mixed": {"anyOf": [{"type": "string", "format": "ipv4"}, {"type": "integer"}]},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"level": {"enum": [1, 2, 3]},
+				"ratio": {"enum": [0.1, 12345678901234567890]},
+				"payload": {},
+				"owner": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"email": {"type": "string"}
+					},
+					"required": ["name"]
+				}
+			},
+			"required": ["destination", "speed", "active", "owner"]
+		}`), &schema)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Field{
+				{Name: "active", Type: TypeBool},
+				{Name: "address", Type: TypeIP},
+				{Name: "destination", Type: TypeString, Required: true, Enum: []interface{}{"Mars", "Venus"}},
+				{Name: "gateway", Type: TypeIP},
+				{Name: "launch", Type: TypeTime},
+				{Name: "level", Type: TypeNumber, Enum: []interface{}{int64(1), int64(2), int64(3)}},
+				{Name: "mixed", Type: TypeAny},
+				{Name: "owner.email", Type: TypeString},
+				{Name: "owner.name", Type: TypeString, Required: true},
+				{Name: "payload", Type: TypeAny},
+				{Name: "ratio", Type: TypeNumber, Enum: []interface{}{big.NewRat(1, 10), new(big.Int).SetUint64(12345678901234567890)}},
+				{Name: "speed", Type: TypeNumber, Required: true},
+				{Name: "tags", Type: TypeList},
+			}, schema.Fields())
+		}
+	})
+
+	t.Run("rejects invalid documents", func(t *testing.T) {
+		for _, document := range []string{
+			`{"type": "string"}`,
+			`{"type": "object", "properties": {"speed": {"type": "quantity"}}}`,
+			`{"type": "object", "properties": {"client-ip": {"type": "string"}}}`,
+			`{"type": "object", "properties": {"speed": {"type": 3}}}`,
+			`{"type": "object", "properties": {"speed": null}}`,
+			`{"type": "object", "properties": {"speed": {"anyOf": [null]}}}`,
+		} {
+			var schema Schema
+			assert.Error(t, json.Unmarshal([]byte(document), &schema), document)
+		}
+	})
+
+	t.Run("exports JSON Schema documents", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Declare(Field{Name: "destination", Type: TypeString, Required: true, Enum: []interface{}{"Mars", "Venus"}}))
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+		assert.NoError(t, schema.Add("release", TypeVersion))
+		assert.NoError(t, schema.Add("network", TypeNetwork))
+		assert.NoError(t, schema.Add("address", TypeIP))
+		assert.NoError(t, schema.Declare(Field{Name: "ratio", Type: TypeNumber, Enum: []interface{}{big.NewRat(1, 4), 2, big.NewRat(1, 3)}}))
+		assert.NoError(t, schema.Add("payload", TypeAny))
+		assert.NoError(t, schema.Require("owner.name", TypeString))
+		assert.NoError(t, schema.Add("owner.email", TypeString))
+
+		document, err := json.Marshal(schema)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"type": "object",
+				"properties": {
+					"address": {"type": "string", "anyOf": [{"format": "ipv4"}, {"format": "ipv6"}]},
+					"destination": {"type": "string", "enum": ["Mars", "Venus"]},
+					"network": {"type": "string", "format": "cidr"},
+					"ratio": {"type": "number", "enum": [0.25, 2, 0.3333333333333333]},
+					"owner": {
+						"type": "object",
+						"properties": {
+							"email": {"type": "string"},
+							"name": {"type": "string"}
+						},
+						"required": ["name"]
+					},
+					"payload": {},
+					"release": {"type": "string", "format": "semver"},
+					"speed": {"type": "number"}
+				},
+				"required": ["destination", "owner"]
+			}`, string(document))
+		}
+	})
+
+	t.Run("round-trips through JSON Schema", func(t *testing.T) {
+		schema := NewSchema()
+		for name, typ := range map[string]Type{
+			"active": TypeBool, "launch": TypeTime, "address": TypeIP, "tags": TypeList, "owner.name": TypeString,
+		} {
+			assert.NoError(t, schema.Require(name, typ))
+		}
+		assert.NoError(t, schema.Add("owner.address.city", TypeString))
+
+		document, err := json.Marshal(schema)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var imported Schema
+		if assert.NoError(t, json.Unmarshal(document, &imported)) {
+			assert.Equal(t, schema.Fields(), imported.Fields())
+		}
+	})
+
+	t.Run("rejects identifiers declared as values and objects", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Add("owner", TypeString))
+		assert.NoError(t, schema.Add("owner.name", TypeString))
+
+		_, err := json.Marshal(schema)
+		assert.Error(t, err)
+	})
+
+	t.Run("checks constants against enumerated values", func(t *testing.T) {
+		var schema Schema
+		assert.NoError(t, json.Unmarshal([]byte(`{
+			"type": "object",
+			"properties": {
+				"destination": {"type": "string", "enum": ["Mars", "Venus"]},
+				"level": {"type": "integer", "enum": [1, 2, 3]}
+			}
+		}`), &schema))
+
+		for _, expression := range []string{
+			`destination == 'Mars' && level != 2`,
+			`destination in ['Mars', 'Venus'] && 3 == level`,
+		} {
+			_, err := Compile(expression, WithSchema(&schema))
+			assert.NoError(t, err, expression)
+		}
+
+		for _, expression := range []string{
+			`destination == 'Pluto'`,
+			`level == 4`,
+			`destination in ['Mars', 'Pluto']`,
+		} {
+			_, err := Compile(expression, WithSchema(&schema))
+			var typeError *TypeError
+			assert.True(t, errors.As(err, &typeError), "%s: %v", expression, err)
+		}
+	})
+}
@@ -2,9 +2,13 @@ package boule
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
+
+	"github.com/victordeleau/boule/internal/units"
 )
 
 // LexerToken pairs a token type with its parsed value.
@@ -15,111 +19,140 @@ type LexerToken struct {
 
 type lexerTokenWithPosition struct {
 	LexerToken
-	position int
+	position int // byte offset of the first character of the token
+	end      int // byte offset following the last character of the token
 }
 
 type lexer struct {
-	position int
-	reader   *bufio.Reader
+	size    int
+	source  *strings.Reader
+	reader  *bufio.Reader
+	decimal bool // read decimal literals as exact *big.Rat values
 }
 
 func newLexer(input string) *lexer {
+	source := strings.NewReader(input)
 	return &lexer{
-		position: 0,
-		reader:   bufio.NewReader(strings.NewReader(input)),
+		size:   len(input),
+		source: source,
+		reader: bufio.NewReader(source),
 	}
 }
 
+// offset returns the byte offset of the next character to read.
+func (l *lexer) offset() int {
+	return l.size - l.source.Len() - l.reader.Buffered()
+}
+
 // Yield scans the string for the next token. It returns the position of the token,
 // the token's type, and the literal identifier.
 func (l *lexer) Yield() *lexerTokenWithPosition {
 
-	var position int
 	var token Token
 	var value interface{}
 
+	position := l.offset()
+
 	c, err := l.reader.ReadByte()
 	if err != nil {
-		return &lexerTokenWithPosition{LexerToken: LexerToken{token: EOF, value: EOF.String()}, position: l.position}
+		return &lexerTokenWithPosition{LexerToken: LexerToken{token: EOF, value: EOF.String()}, position: position, end: position}
 	}
 
 	switch c {
 	case '=':
-		position = l.position
 		token = l.lexEqual()
 		value = token.String()
 
 	case '!':
-		position = l.position
 		token = l.lexExclamation()
 		value = token.String()
 
 	case '>':
-		position = l.position
 		token = l.lexGreater()
 		value = token.String()
 
 	case '<':
-		position = l.position
 		token = l.lexLess()
 		value = token.String()
 
 	case '&':
-		position = l.position
 		token = l.lexAnd()
 		value = token.String()
 
 	case '|':
-		position = l.position
 		token = l.lexOr()
 		value = token.String()
 
+	case '.':
+		token = l.lexRange()
+		value = token.String()
+
+	case '^':
+		token = BIT_XOR
+		value = BIT_XOR.String()
+
+	case '~':
+		token = l.lexTilde()
+		value = token.String()
+
 	case '"', '\'':
-		position = l.position
 		token, value = l.lexString()
 
 	case '(':
-		position = l.position
 		token = OPEN
 		value = OPEN.String()
 
 	case ')':
-		position = l.position
 		token = CLOSE
 		value = CLOSE.String()
 
+	case '[':
+		token = OPEN_LIST
+		value = OPEN_LIST.String()
+
+	case ']':
+		token = CLOSE_LIST
+		value = CLOSE_LIST.String()
+
+	case ',':
+		token = COMMA
+		value = COMMA.String()
+
+	case ':', '$':
+		token, value = l.lexParam(c)
+
 	default:
 		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
 			return l.Yield() // move on to next token
 
-		} else if c >= '0' && c <= '9' {
-			position = l.position
+		} else if isDigit(c) {
 			if l.backup() == EOF {
 				break
 			}
 			token, value = l.lexNumber()
 
 		} else if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
-			position = l.position
 			if l.backup() == EOF {
 				break
 			}
 			token, value = l.lexIdent()
+			if keyword, ok := keywords[value.(string)]; ok {
+				token = keyword
+			} else if typed, ok := typedStrings[value.(string)]; ok && l.quoteFollows() {
+				_, value = l.lexString()
+				token = typed
+			}
 
 		} else {
-			position = l.position
 			token = ILLEGAL
 			value = ILLEGAL.String()
 		}
 	}
 
-	l.position++
-
-	return &lexerTokenWithPosition{LexerToken: LexerToken{token: token, value: value}, position: position}
+	return &lexerTokenWithPosition{LexerToken: LexerToken{token: token, value: value}, position: position, end: l.offset()}
 }
 
 func (l *lexer) backup() Token {
-	l.position--
 	if err := l.reader.UnreadByte(); err != nil {
 		return EOF
 	}
@@ -128,24 +161,24 @@ func (l *lexer) backup() Token {
 
 func (l *lexer) lexEqual() Token {
 
-	l.position++
-
 	c, err := l.reader.ReadByte()
 	if err != nil {
-		return EOF
+		return ASSIGN
 	}
 
-	if c != '=' {
-		return ILLEGAL
+	if c == '=' { // ==
+		return EQUAL
 	}
 
-	return EQUAL // ==
+	if l.backup() == EOF {
+		return EOF
+	}
+
+	return ASSIGN
 }
 
 func (l *lexer) lexExclamation() Token {
 
-	l.position++
-
 	c, err := l.reader.ReadByte()
 	if err != nil {
 		return EOF
@@ -164,8 +197,6 @@ func (l *lexer) lexExclamation() Token {
 
 func (l *lexer) lexGreater() Token {
 
-	l.position++
-
 	c, err := l.reader.ReadByte()
 	if err != nil {
 		return GREATER
@@ -175,6 +206,10 @@ func (l *lexer) lexGreater() Token {
 		return GREATER_OR_EQUAL
 	}
 
+	if c == '>' { // >>
+		return SHIFT_RIGHT
+	}
+
 	if l.backup() == EOF {
 		return EOF
 	}
@@ -184,8 +219,6 @@ func (l *lexer) lexGreater() Token {
 
 func (l *lexer) lexLess() Token {
 
-	l.position++
-
 	c, err := l.reader.ReadByte()
 	if err != nil {
 		return LESS
@@ -195,6 +228,10 @@ func (l *lexer) lexLess() Token {
 		return LESS_OR_EQUAL
 	}
 
+	if c == '<' { // <<
+		return SHIFT_LEFT
+	}
+
 	if l.backup() == EOF {
 		return EOF
 	}
@@ -202,96 +239,220 @@ func (l *lexer) lexLess() Token {
 	return LESS
 }
 
-func (l *lexer) lexAnd() Token {
-
-	l.position++
+func (l *lexer) lexRange() Token {
 
 	c, err := l.reader.ReadByte()
 	if err != nil {
-		return EOF
+		return ILLEGAL
 	}
 
-	if c != '&' {
+	if c != '.' {
+		if l.backup() == EOF {
+			return EOF
+		}
 		return ILLEGAL
 	}
 
-	return AND
+	c, err = l.reader.ReadByte()
+	if err != nil {
+		return RANGE
+	}
+
+	if c == '<' { // ..<
+		return RANGE_EXCLUSIVE
+	}
+
+	if l.backup() == EOF {
+		return EOF
+	}
+
+	return RANGE // ..
 }
 
-func (l *lexer) lexOr() Token {
+// rangeFollows reports whether the next characters are a range operator, which ends any number
+// or identifier being scanned.
+func (l *lexer) rangeFollows() bool {
+	next, err := l.reader.Peek(2)
+	return err == nil && next[0] == '.' && next[1] == '.'
+}
 
-	l.position++
+func (l *lexer) lexAnd() Token {
 
 	c, err := l.reader.ReadByte()
 	if err != nil {
+		return BIT_AND
+	}
+
+	if c == '&' { // &&
+		return AND
+	}
+
+	if c == '^' { // &^
+		return BIT_AND_NOT
+	}
+
+	if l.backup() == EOF {
 		return EOF
 	}
 
-	if c != '|' {
+	return BIT_AND
+}
+
+func (l *lexer) lexTilde() Token {
+
+	c, err := l.reader.ReadByte()
+	if err != nil {
 		return ILLEGAL
 	}
 
-	return OR
+	if c == '~' { // ~~
+		return APPROX
+	}
+
+	if l.backup() == EOF {
+		return EOF
+	}
+
+	return ILLEGAL
+}
+
+func (l *lexer) lexOr() Token {
+
+	c, err := l.reader.ReadByte()
+	if err != nil {
+		return BIT_OR
+	}
+
+	if c == '|' { // ||
+		return OR
+	}
+
+	if l.backup() == EOF {
+		return EOF
+	}
+
+	return BIT_OR
 }
 
 func (l *lexer) lexNumber() (Token, interface{}) {
-	var dotFound bool
 	var b strings.Builder
 	for {
-		l.position++
+		if l.rangeFollows() {
+			break
+		}
 
 		c, err := l.reader.ReadByte()
 		if err != nil {
-			literal := b.String()
-			if dotFound {
-				value, err := strconv.ParseFloat(literal, 64)
-				if err != nil {
-					return ILLEGAL, 0
-				}
-				return FLOAT, value
-			} else {
-				integer, ok := (&big.Int{}).SetString(literal, 10)
-				if !ok {
-					return ILLEGAL, 0
-				}
-				return INTEGER, integer
-			}
+			break
 		}
 
-		if c < '0' || c > '9' {
-			if c == '.' {
-				if dotFound {
-					return ILLEGAL, 0
-				}
-				dotFound = true
-				b.WriteByte('.')
-				continue
-			}
-			_ = l.backup()
-			literal := b.String()
-			if dotFound {
-				value, err := strconv.ParseFloat(literal, 64)
-				if err != nil {
-					return ILLEGAL, 0
-				}
-				return FLOAT, value
-			} else {
-				integer, ok := (&big.Int{}).SetString(literal, 10)
-				if !ok {
-					return ILLEGAL, 0
-				}
-				return INTEGER, integer
-			}
+		if isDigit(c) || isLetter(c) || c == '_' || c == '.' || ((c == '+' || c == '-') && isExponent(b.String())) {
+			b.WriteByte(c)
+			continue
 		}
 
-		b.WriteByte(c)
+		if c == '%' { // percentage, ends the literal
+			b.WriteByte(c)
+			break
+		}
+
+		_ = l.backup()
+		break
 	}
+
+	literal := b.String()
+
+	if number, factor, ok := units.Split(literal); ok {
+		if token, _ := l.numberLiteral(literal); token == ILLEGAL { // e.g. 0x1B is a number, not 1 byte
+			return l.unitLiteral(number, factor)
+		}
+	}
+
+	return l.numberLiteral(literal)
+}
+
+// unitLiteral converts a number followed by a unit, e.g. 512MB or 20%, to the number in the
+// canonical unit: bytes, or a fraction for percentages. Whole numbers yield an INTEGER token, and
+// the other ones a FLOAT token.
+func (l *lexer) unitLiteral(number string, factor *big.Rat) (Token, interface{}) {
+
+	token, value := l.numberLiteral(number)
+	if token == ILLEGAL {
+		return token, value
+	}
+
+	r, _ := toRat(value)
+	r = new(big.Rat).Mul(r, factor)
+
+	if r.IsInt() {
+		return INTEGER, new(big.Int).Set(r.Num())
+	}
+
+	if l.decimal {
+		return FLOAT, r
+	}
+
+	f, _ := r.Float64()
+	return FLOAT, f
+}
+
+// numberLiteral converts a scanned number to an INTEGER (*big.Int) or FLOAT token. Integers with
+// leading zeros but no base prefix are decimal, e.g. 0755 is 755. The value of
+// FLOAT tokens is a float64, or an exact *big.Rat in decimal mode. Malformed literals yield an
+// ILLEGAL token whose value describes the problem.
+func (l *lexer) numberLiteral(literal string) (Token, interface{}) {
+
+	if !isFloatLiteral(literal) {
+		integer, ok := (&big.Int{}).SetString(integerLiteral(literal), 0)
+		if !ok {
+			return ILLEGAL, fmt.Sprintf("malformed number literal '%s'", literal)
+		}
+		return INTEGER, integer
+	}
+
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil && !(l.decimal && errors.Is(err, strconv.ErrRange)) {
+		if errors.Is(err, strconv.ErrRange) {
+			return ILLEGAL, fmt.Sprintf("number literal '%s' out of range", literal)
+		}
+		return ILLEGAL, fmt.Sprintf("malformed number literal '%s'", literal)
+	}
+
+	if l.decimal {
+		decimal, ok := new(big.Rat).SetString(strings.ReplaceAll(literal, "_", ""))
+		if !ok {
+			return ILLEGAL, fmt.Sprintf("malformed number literal '%s'", literal)
+		}
+		return FLOAT, decimal
+	}
+
+	return FLOAT, value
+}
+
+// isFloatLiteral reports whether a number literal is a float rather than an integer.
+func isFloatLiteral(literal string) bool {
+	if len(literal) > 1 && literal[0] == '0' && (literal[1] == 'x' || literal[1] == 'X') {
+		return strings.ContainsAny(literal, ".pP")
+	}
+	return strings.ContainsAny(literal, ".eE") && !(len(literal) > 1 && literal[0] == '0' && strings.ContainsAny(literal[1:2], "bBoO"))
+}
+
+// isExponent reports whether a partially scanned number literal ends with an exponent marker,
+// which may be followed by a sign.
+func isExponent(literal string) bool {
+	if literal == "" {
+		return false
+	}
+	last := literal[len(literal)-1]
+	if len(literal) > 1 && literal[0] == '0' && (literal[1] == 'x' || literal[1] == 'X') {
+		return last == 'p' || last == 'P'
+	}
+	return last == 'e' || last == 'E'
 }
 
 func (l *lexer) lexString() (Token, string) {
 	var b strings.Builder
 	for {
-		l.position++
 
 		c, err := l.reader.ReadByte()
 		if err != nil || c == '"' || c == '\'' {
@@ -302,10 +463,25 @@ func (l *lexer) lexString() (Token, string) {
 	}
 }
 
+// quoteFollows consumes the next character if it is a string quote.
+func (l *lexer) quoteFollows() bool {
+
+	next, err := l.reader.Peek(1)
+	if err != nil || (next[0] != '"' && next[0] != '\'') {
+		return false
+	}
+
+	_, _ = l.reader.ReadByte()
+
+	return true
+}
+
 func (l *lexer) lexIdent() (Token, string) {
 	var b strings.Builder
 	for {
-		l.position++
+		if l.rangeFollows() {
+			break
+		}
 
 		c, err := l.reader.ReadByte()
 		if err != nil {
@@ -322,6 +498,52 @@ func (l *lexer) lexIdent() (Token, string) {
 	return IDENT, b.String()
 }
 
+// lexParam scans a placeholder, either named (:threshold) or positional ($1).
+func (l *lexer) lexParam(sigil byte) (Token, interface{}) {
+
+	c, err := l.reader.ReadByte()
+	if err != nil {
+		return ILLEGAL, ILLEGAL.String()
+	}
+
+	if l.backup() == EOF {
+		return EOF, EOF.String()
+	}
+
+	if sigil == ':' {
+		if !isLetter(c) {
+			return ILLEGAL, ILLEGAL.String()
+		}
+		_, name := l.lexIdent()
+		return PARAM, ":" + name
+	}
+
+	if c < '1' || c > '9' {
+		return ILLEGAL, ILLEGAL.String()
+	}
+
+	var b strings.Builder
+	for {
+
+		c, err = l.reader.ReadByte()
+		if err != nil {
+			break
+		}
+		if c < '0' || c > '9' {
+			_ = l.backup()
+			break
+		}
+
+		b.WriteByte(c)
+	}
+
+	return PARAM, "$" + b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
 func isLetter(c byte) bool {
 	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 }
@@ -3,6 +3,7 @@ package boule
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 )
 
@@ -30,3 +31,93 @@ func TestLexer(t *testing.T) {
 		})
 	}
 }
+
+func TestLexer_Number(t *testing.T) {
+
+	bigInt := func(s string) *big.Int {
+		i, _ := new(big.Int).SetString(s, 10)
+		return i
+	}
+
+	for _, test := range []struct {
+		literal string
+		token   Token
+		value   interface{}
+	}{
+		{"42", INTEGER, big.NewInt(42)},
+		{"0xFF", INTEGER, big.NewInt(255)},
+		{"0Xff", INTEGER, big.NewInt(255)},
+		{"0b1010", INTEGER, big.NewInt(10)},
+		{"0o17", INTEGER, big.NewInt(15)},
+		{"017", INTEGER, big.NewInt(17)},
+		{"0755", INTEGER, big.NewInt(755)},
+		{"08", INTEGER, big.NewInt(8)},
+		{"00", INTEGER, big.NewInt(0)},
+		{"1_000_000", INTEGER, big.NewInt(1000000)},
+		{"0x_FF_FF", INTEGER, big.NewInt(65535)},
+		{"340282366920938463463374607431768211456", INTEGER, bigInt("340282366920938463463374607431768211456")},
+		{"280.32", FLOAT, 280.32},
+		{"6.02e23", FLOAT, 6.02e23},
+		{"1E-3", FLOAT, 0.001},
+		{"1e+3", FLOAT, 1000.0},
+		{"1_000.5", FLOAT, 1000.5},
+		{".5", ILLEGAL, nil},
+		{"0x1p-2", FLOAT, 0.25},
+		{"0x1.8p1", FLOAT, 3.0},
+		{"0xFF.8", ILLEGAL, nil},
+		{"0x", ILLEGAL, nil},
+		{"0b102", ILLEGAL, nil},
+		{"0_", ILLEGAL, nil},
+		{"1__000", ILLEGAL, nil},
+		{"1_", ILLEGAL, nil},
+		{"1e", ILLEGAL, nil},
+		{"1e400", ILLEGAL, nil},
+		{"0b1.0", ILLEGAL, nil},
+		{"280.32.", ILLEGAL, nil},
+		{"12abc", ILLEGAL, nil},
+		{"512MB", INTEGER, big.NewInt(512000000)},
+		{"1.5GiB", INTEGER, big.NewInt(1610612736)},
+		{"64B", INTEGER, big.NewInt(64)},
+		{"1_024KiB", INTEGER, big.NewInt(1048576)},
+		{"0x1B", INTEGER, big.NewInt(27)},
+		{"20%", FLOAT, 0.2},
+		{"100%", INTEGER, big.NewInt(1)},
+		{"0.5B", FLOAT, 0.5},
+		{"12XB", ILLEGAL, nil},
+		{"MB", IDENT, nil},
+	} {
+		t.Run(test.literal, func(t *testing.T) {
+
+			token := newLexer(test.literal).Yield()
+
+			assert.Equal(t, test.token, token.token)
+			if test.value != nil {
+				assert.Equal(t, test.value, token.value)
+			}
+		})
+	}
+
+	t.Run("decimal mode", func(t *testing.T) {
+
+		lexer := newLexer("0.1 1_000.25 6.02e23 1e400")
+		lexer.decimal = true
+
+		for _, expected := range []*big.Rat{
+			big.NewRat(1, 10),
+			big.NewRat(4001, 4),
+			new(big.Rat).SetInt(bigInt("602000000000000000000000")),
+			new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(400), nil)),
+		} {
+			token := lexer.Yield()
+			assert.Equal(t, FLOAT, token.token)
+			assert.Equal(t, 0, expected.Cmp(token.value.(*big.Rat)))
+		}
+	})
+
+	t.Run("malformed literal error is positioned", func(t *testing.T) {
+		_, err := NewExpression(`mask == 0xZZ`)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "malformed number literal '0xZZ'")
+		}
+	})
+}
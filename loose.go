@@ -0,0 +1,130 @@
+package boule
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// comparator applies a comparison operator to two evaluated operands.
+type comparator func(left, right interface{}, token Token, position int) (interface{}, error)
+
+// loosen returns a comparator that coerces operands to a common type before comparing them with
+// the given comparator. Null is only equal to null, and is neither lower nor greater than any value.
+func loosen(compare comparator) comparator {
+	return func(left, right interface{}, token Token, position int) (interface{}, error) {
+
+		left, right = coerce(left, right)
+
+		if left == nil || right == nil {
+			switch token {
+			case EQUAL:
+				return left == right, nil
+			case NOT_EQUAL:
+				return left != right, nil
+			default:
+				return false, nil
+			}
+		}
+
+		return compare(left, right, token, position)
+	}
+}
+
+// coerce converts one of the operands to the type of the other one when their types differ:
+//   - a string compared with a number is read as a decimal number, or the number is written as a
+//     string if the string isn't a decimal number;
+//   - a string compared with a boolean is read as a boolean, or the boolean is written as a string
+//     if the string isn't "true" or "false";
+//   - a boolean compared with a number is read as 1 or 0.
+func coerce(left, right interface{}) (interface{}, interface{}) {
+	if l, r, ok := coercePair(left, right); ok {
+		return l, r
+	}
+	if r, l, ok := coercePair(right, left); ok {
+		return l, r
+	}
+	return left, right
+}
+
+func coercePair(a, b interface{}) (interface{}, interface{}, bool) {
+
+	isNumber := typeTests["number"]
+
+	switch av := a.(type) {
+	case string:
+		switch bv := b.(type) {
+		case bool:
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(av)); err == nil {
+				return parsed, bv, true
+			}
+			return av, strconv.FormatBool(bv), true
+		default:
+			if !isNumber(b) {
+				return nil, nil, false
+			}
+			if parsed, ok := parseDecimal(strings.TrimSpace(av)); ok {
+				return parsed, b, true
+			}
+			s, _ := callString([]interface{}{b})
+			return av, s, true
+		}
+
+	case bool:
+		if !isNumber(b) {
+			return nil, nil, false
+		}
+		if av {
+			return 1, b, true
+		}
+		return 0, b, true
+	}
+
+	return nil, nil, false
+}
+
+// parseDecimal parses a decimal number, e.g. "42", "-0.5" or "1e3", exactly. Unlike big.Rat, it
+// rejects fractions such as "1/2", and hexadecimal, octal and binary numbers.
+func parseDecimal(s string) (*big.Rat, bool) {
+
+	if strings.ContainsAny(s, "xX") {
+		return nil, false // hexadecimal mantissas, which ParseFloat accepts
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(s) // exact, and rejects "inf" and "nan"
+}
+
+// truthy returns the boolean value of any value in loose mode: null, false, zero, the empty string
+// and the empty list are false, and every other value is true.
+func truthy(value interface{}) bool {
+
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case *big.Rat:
+		return v.Sign() != 0
+	}
+
+	i64, bi, f64, kind := toNumeric(value)
+	switch kind {
+	case numInt64:
+		return i64 != 0
+	case numBigInt:
+		return bi.Sign() != 0
+	case numFloat64:
+		return f64 != 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,90 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLooseTypes(t *testing.T) {
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"quantity": "5",
+		"price":    "19.99",
+		"rating":   4,
+		"active":   "true",
+		"archived": false,
+		"label":    "abc",
+		"note":     nil,
+		"tags":     []interface{}{"1", "2"},
+		"empty":    "",
+		"ratio":    "1/2",
+		"hex":      "0x10",
+	}))
+
+	evaluate := func(t *testing.T, expression string, opts ...Option) (bool, error) {
+		evaluate, err := NewExpression(expression, opts...)
+		if !assert.NoError(t, err, expression) {
+			return false, err
+		}
+		return evaluate(data)
+	}
+
+	t.Run("coerces operands in loose mode", func(t *testing.T) {
+		for _, expression := range []string{
+			`quantity == 5 && 5 == quantity && quantity < 10 && price > 19.9`,
+			`active == true && archived == 'false' && archived == 0 && true == 1`,
+			`label != 5 && label == 'abc' && label > 100`,
+			`note == note && note != 0 && !(note < 1) && !(note > 1)`,
+			`1 in tags && rating in ['3', '4'] && quantity between 1 and 9`,
+			`quantity && label && !empty && !note && !archived && tags`,
+			`rating`,
+		} {
+			result, err := evaluate(t, expression, WithLooseTypes())
+			assert.NoError(t, err, expression)
+			assert.True(t, result, expression)
+		}
+
+		result, err := evaluate(t, `empty || note`, WithLooseTypes())
+		assert.NoError(t, err)
+		assert.False(t, result)
+	})
+
+	t.Run("numeric strings are decimal only", func(t *testing.T) {
+		for _, expression := range []string{
+			`ratio != 0.5 && ratio == '1/2'`,
+			`hex != 16 && hex == '0x10'`,
+			`'1e3' == 1000 && ' 0.25 ' == 0.25 && '010' == 10`,
+			`'inf' != 0 && '0b1' != 1 && '0o7' != 7`,
+		} {
+			result, err := evaluate(t, expression, WithLooseTypes())
+			assert.NoError(t, err, expression)
+			assert.True(t, result, expression)
+		}
+	})
+
+	t.Run("parameters of any type are accepted in loose mode", func(t *testing.T) {
+		evaluate, err := NewParameterizedExpression(`rating > :minimum`, WithLooseTypes())
+		assert.NoError(t, err)
+
+		params := NewParams()
+		assert.NoError(t, params.Set("minimum", "3"))
+
+		result, err := evaluate(data, params)
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+
+	t.Run("strict mode is the default", func(t *testing.T) {
+		for _, expression := range []string{
+			`quantity == 5`,
+			`active == true`,
+			`rating`,
+			`!label`,
+			`label && true`,
+		} {
+			_, err := evaluate(t, expression)
+			assert.Error(t, err, expression)
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package boule
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// numberArgument returns the argument at the given index, which must be a number.
+func numberArgument(arguments []interface{}, index int) (interface{}, error) {
+	if isDecimal(arguments[index]) {
+		return arguments[index], nil
+	}
+	if _, _, _, kind := toNumeric(arguments[index]); kind == numNone {
+		return nil, fmt.Errorf("expected argument %d of type 'number', got '%T'", index+1, arguments[index])
+	}
+	return arguments[index], nil
+}
+
+// toFloat converts a number to the nearest float64.
+func toFloat(v interface{}) float64 {
+	if r, ok := v.(*big.Rat); ok {
+		f, _ := r.Float64()
+		return f
+	}
+	i64, bi, f64, kind := toNumeric(v)
+	switch kind {
+	case numInt64:
+		return float64(i64)
+	case numBigInt:
+		f, _ := new(big.Float).SetInt(bi).Float64()
+		return f
+	default:
+		return f64
+	}
+}
+
+// toInteger returns the number as a *big.Int if it is an integer type, e.g. int64 or *big.Int.
+func toInteger(v interface{}) (*big.Int, bool) {
+	i64, bi, _, kind := toNumeric(v)
+	if kind != numInt64 && kind != numBigInt {
+		return nil, false
+	}
+	return promoteToBI(i64, bi, kind), true
+}
+
+// less reports whether the number a is lower than the number b.
+func less(a, b interface{}) (bool, error) {
+	result, err := compare(a, b, LESS, 0)
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// callAbs returns the absolute value of a number, of the same kind as the number.
+func callAbs(arguments []interface{}) (interface{}, error) {
+	v, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, ok := v.(*big.Rat); ok {
+		return new(big.Rat).Abs(r), nil
+	}
+
+	i64, bi, f64, kind := toNumeric(v)
+	switch {
+	case kind == numInt64 && i64 == math.MinInt64:
+		return new(big.Int).Neg(big.NewInt(i64)), nil
+	case kind == numInt64 && i64 < 0:
+		return -i64, nil
+	case kind == numInt64:
+		return i64, nil
+	case kind == numBigInt:
+		return new(big.Int).Abs(bi), nil
+	default:
+		return math.Abs(f64), nil
+	}
+}
+
+// callMin returns the lowest of its arguments.
+func callMin(arguments []interface{}) (interface{}, error) {
+	return extremum(arguments, false)
+}
+
+// callMax returns the greatest of its arguments.
+func callMax(arguments []interface{}) (interface{}, error) {
+	return extremum(arguments, true)
+}
+
+func extremum(arguments []interface{}, greatest bool) (interface{}, error) {
+
+	result, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(arguments); i++ {
+		v, err := numberArgument(arguments, i)
+		if err != nil {
+			return nil, err
+		}
+
+		a, b := v, result
+		if greatest {
+			a, b = result, v
+		}
+		replace, err := less(a, b)
+		if err != nil {
+			return nil, err
+		}
+		if replace {
+			result = v
+		}
+	}
+
+	return result, nil
+}
+
+// callClamp restricts a number to the range between a low and a high bound.
+func callClamp(arguments []interface{}) (interface{}, error) {
+
+	var values [3]interface{}
+	for i := range values {
+		var err error
+		if values[i], err = numberArgument(arguments, i); err != nil {
+			return nil, err
+		}
+	}
+	v, low, high := values[0], values[1], values[2]
+
+	if inverted, err := less(high, low); err != nil || inverted {
+		return nil, fmt.Errorf("low bound is greater than high bound")
+	}
+
+	if below, err := less(v, low); err != nil || below {
+		return low, err
+	}
+	if above, err := less(high, v); err != nil || above {
+		return high, err
+	}
+	return v, nil
+}
+
+// callFloor returns the greatest integer lower than or equal to a number.
+func callFloor(arguments []interface{}) (interface{}, error) {
+	return roundNumber(arguments, math.Floor, floorRat)
+}
+
+// callCeil returns the lowest integer greater than or equal to a number.
+func callCeil(arguments []interface{}) (interface{}, error) {
+	return roundNumber(arguments, math.Ceil, func(r *big.Rat) *big.Int {
+		return new(big.Int).Neg(floorRat(new(big.Rat).Neg(r)))
+	})
+}
+
+// callRound returns the nearest integer to a number, rounding half away from zero.
+func callRound(arguments []interface{}) (interface{}, error) {
+	return roundNumber(arguments, math.Round, func(r *big.Rat) *big.Int {
+		rounded := floorRat(new(big.Rat).Add(new(big.Rat).Abs(r), big.NewRat(1, 2)))
+		if r.Sign() < 0 {
+			rounded.Neg(rounded)
+		}
+		return rounded
+	})
+}
+
+// roundNumber rounds a number to an integer. Integers are returned unchanged, float64 values are
+// rounded to float64 values and decimals to integral decimals.
+func roundNumber(arguments []interface{}, roundFloat func(float64) float64, roundRat func(*big.Rat) *big.Int) (interface{}, error) {
+
+	v, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, ok := v.(*big.Rat); ok {
+		return new(big.Rat).SetInt(roundRat(r)), nil
+	}
+
+	if _, ok := toInteger(v); ok {
+		return v, nil
+	}
+
+	return roundFloat(toFloat(v)), nil
+}
+
+func floorRat(r *big.Rat) *big.Int {
+	// Euclidean division rounds toward negative infinity, the denominator being positive.
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+// callPow returns a number raised to a power. An integer raised to a non-negative integer power is an
+// exact *big.Int, and a decimal raised to an integer power is an exact decimal.
+func callPow(arguments []interface{}) (interface{}, error) {
+
+	base, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := numberArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	n, integral := toInteger(exponent)
+	if integral && n.IsInt64() {
+		e := n.Int64()
+
+		if b, ok := toInteger(base); ok && e >= 0 {
+			if b.BitLen() > 1 && e > maxValueSize/int64(b.BitLen()) {
+				return nil, fmt.Errorf("result exceeds %d bits", maxValueSize)
+			}
+			return new(big.Int).Exp(b, n, nil), nil
+		}
+
+		if r, ok := base.(*big.Rat); ok {
+			if r.Sign() == 0 && e < 0 {
+				return nil, fmt.Errorf("zero raised to a negative power")
+			}
+			abs := new(big.Int).Abs(n)
+			bits := r.Num().BitLen() + r.Denom().BitLen()
+			if bits > 2 && abs.Cmp(big.NewInt(int64(maxValueSize/bits))) > 0 {
+				return nil, fmt.Errorf("result exceeds %d bits", maxValueSize)
+			}
+			num := new(big.Int).Exp(r.Num(), abs, nil)
+			denom := new(big.Int).Exp(r.Denom(), abs, nil)
+			if e < 0 {
+				num, denom = denom, num
+			}
+			return new(big.Rat).SetFrac(num, denom), nil
+		}
+	}
+
+	return math.Pow(toFloat(base), toFloat(exponent)), nil
+}
+
+// callSqrt returns the square root of a non-negative number. The square root of a perfect square
+// integer is an exact *big.Int.
+func callSqrt(arguments []interface{}) (interface{}, error) {
+
+	v, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := toInteger(v); ok {
+		if b.Sign() < 0 {
+			return nil, fmt.Errorf("square root of negative number")
+		}
+		root := new(big.Int).Sqrt(b)
+		if new(big.Int).Mul(root, root).Cmp(b) == 0 {
+			return root, nil
+		}
+	}
+
+	f := toFloat(v)
+	if f < 0 {
+		return nil, fmt.Errorf("square root of negative number")
+	}
+	return math.Sqrt(f), nil
+}
+
+// callLog returns the natural logarithm of a positive number, or its logarithm in the optional base.
+func callLog(arguments []interface{}) (interface{}, error) {
+
+	v, err := numberArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	f := toFloat(v)
+	if f <= 0 {
+		return nil, fmt.Errorf("logarithm of non-positive number")
+	}
+
+	if len(arguments) == 1 {
+		return math.Log(f), nil
+	}
+
+	base, err := numberArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	b := toFloat(base)
+	if b <= 0 || b == 1 {
+		return nil, fmt.Errorf("invalid logarithm base")
+	}
+	return math.Log(f) / math.Log(b), nil
+}
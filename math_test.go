@@ -0,0 +1,99 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestMathFunctions(t *testing.T) {
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"delta":     -0.004,
+		"tolerance": 0.01,
+		"balance":   int64(-25),
+		"huge":      new(big.Int).Lsh(big.NewInt(1), 80),
+		"count":     uint8(9),
+	}))
+
+	for _, expression := range []string{
+		`abs(delta) < tolerance && abs(balance) == 25 && abs(huge) == huge`,
+		`min(3, 1.5, 2) == 1.5 && max(balance, count, 4) == 9`,
+		`round(2.5) == 3 && round(2.4) == 2 && floor(2.7) == 2 && ceil(2.1) == 3 && floor(balance) == balance`,
+		`pow(2, 100) > huge && pow(2, 0.5) > 1.41 && pow(4, 0.5) == 2`,
+		`sqrt(count) == 3 && sqrt(2) > 1.414 && sqrt(2) < 1.415`,
+		`log(1) == 0 && log(1000, 10) > 2.999 && log(8, 2) < 3.001`,
+		`clamp(150, 0, 100) == 100 && clamp(balance, 0, 100) == 0 && clamp(50, 0, 100) == 50`,
+		`abs(min(delta, balance)) == 25`,
+	} {
+		evaluate, err := NewExpression(expression)
+		if !assert.NoError(t, err, expression) {
+			continue
+		}
+		result, err := evaluate(data)
+		assert.NoError(t, err, expression)
+		assert.True(t, result, expression)
+	}
+
+	t.Run("integers are preserved", func(t *testing.T) {
+		result, err := callAbs([]interface{}{int64(math.MinInt64)})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, new(big.Int).Neg(big.NewInt(math.MinInt64)).Cmp(result.(*big.Int)))
+
+		result, err = callPow([]interface{}{big.NewInt(10), 20})
+		assert.NoError(t, err)
+		assert.Equal(t, "100000000000000000000", result.(*big.Int).String())
+
+		result, err = callMax([]interface{}{big.NewInt(7), 3.5})
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(7), result)
+
+		result, err = callSqrt([]interface{}{new(big.Int).Lsh(big.NewInt(1), 100)})
+		assert.NoError(t, err)
+		assert.Equal(t, new(big.Int).Lsh(big.NewInt(1), 50), result)
+
+		result, err = callFloor([]interface{}{uint(3)})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), result)
+	})
+
+	t.Run("decimals are exact", func(t *testing.T) {
+		result, err := callRound([]interface{}{big.NewRat(-5, 2)})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, big.NewRat(-3, 1).Cmp(result.(*big.Rat)))
+
+		result, err = callCeil([]interface{}{big.NewRat(-5, 2)})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, big.NewRat(-2, 1).Cmp(result.(*big.Rat)))
+
+		result, err = callPow([]interface{}{big.NewRat(11, 10), -2})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, big.NewRat(100, 121).Cmp(result.(*big.Rat)))
+
+		evaluate, err := NewExpression(`pow(1.1, 2) == 1.21 && round(2.675) == 3`, WithDecimal())
+		assert.NoError(t, err)
+		result, err = evaluate(NewData())
+		assert.NoError(t, err)
+		assert.Equal(t, true, result)
+	})
+
+	t.Run("rejects invalid arguments", func(t *testing.T) {
+		for _, expression := range []string{
+			`abs('1') == 1`,
+			`min(1) == 1`,
+			`sqrt(balance) == 5`,
+			`log(0) == 0`,
+			`log(8, 1) == 0`,
+			`clamp(5, 10, 0) == 5`,
+			`pow(3, 100000) > 0`,
+		} {
+			evaluate, err := NewExpression(expression)
+			if err == nil {
+				_, err = evaluate(data)
+			}
+			assert.Error(t, err, expression)
+		}
+	})
+}
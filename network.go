@@ -0,0 +1,196 @@
+package boule
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// LiteralIP represents an IPv4 or IPv6 address literal, e.g. ip'10.0.0.1'.
+type LiteralIP struct {
+	value    netip.Addr
+	position int
+	end      int
+}
+
+// Evaluate returns the IP address.
+func (l *LiteralIP) Evaluate(_ *Data) (interface{}, error) {
+	return l.value, nil
+}
+
+// Value returns the IP address.
+func (l *LiteralIP) Value() netip.Addr { return l.value }
+
+// Pos returns the offset of the literal.
+func (l *LiteralIP) Pos() int { return l.position }
+
+// End returns the offset following the literal.
+func (l *LiteralIP) End() int { return l.end }
+
+// LiteralCIDR represents an IPv4 or IPv6 network literal in CIDR notation, e.g. cidr'10.0.0.0/8'.
+type LiteralCIDR struct {
+	value    netip.Prefix
+	position int
+	end      int
+}
+
+// Evaluate returns the network prefix.
+func (l *LiteralCIDR) Evaluate(_ *Data) (interface{}, error) {
+	return l.value, nil
+}
+
+// Value returns the network prefix.
+func (l *LiteralCIDR) Value() netip.Prefix { return l.value }
+
+// Pos returns the offset of the literal.
+func (l *LiteralCIDR) Pos() int { return l.position }
+
+// End returns the offset following the literal.
+func (l *LiteralCIDR) End() int { return l.end }
+
+func parseIP(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+func parseCIDR(s string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if prefix.Addr().Is4In6() {
+		if prefix.Bits() < 96 {
+			return netip.Prefix{}, fmt.Errorf("IPv4-mapped network %s must have a prefix length of at least 96", s)
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// normalizeNetwork converts IP addresses (net.IP) and networks (*net.IPNet) to netip.Addr and
+// netip.Prefix, unmapping IPv4-mapped addresses and masking networks.
+func normalizeNetwork(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case netip.Addr:
+		if !v.IsValid() {
+			return nil, fmt.Errorf("invalid IP address")
+		}
+		return v.Unmap(), nil
+	case net.IP:
+		addr, ok := netip.AddrFromSlice(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid IP address %v", v)
+		}
+		return addr.Unmap(), nil
+	case netip.Prefix:
+		if !v.IsValid() {
+			return nil, fmt.Errorf("invalid IP network")
+		}
+		return v.Masked(), nil
+	case *net.IPNet:
+		if v == nil {
+			return nil, fmt.Errorf("invalid IP network <nil>")
+		}
+		addr, ok := netip.AddrFromSlice(v.IP)
+		ones, _ := v.Mask.Size()
+		if !ok {
+			return nil, fmt.Errorf("invalid IP network %v", v)
+		}
+		if addr.Is4In6() && len(v.Mask) == net.IPv4len {
+			addr = addr.Unmap()
+		}
+		return netip.PrefixFrom(addr, ones).Masked(), nil
+	}
+	return nil, fmt.Errorf("'value' type %T is not supported", value)
+}
+
+// compareIP compares an IP address with another address, which may be given in its string form.
+func compareIP(left, right interface{}, token Token, position int) (interface{}, error) {
+
+	var addresses [2]netip.Addr
+
+	for i, operand := range []interface{}{left, right} {
+		switch o := operand.(type) {
+		case netip.Addr:
+			addresses[i] = o
+		case string:
+			addr, err := parseIP(o)
+			if err != nil {
+				return false, newTypeError(position, "can't compare IP address with malformed IP address %q", o)
+			}
+			addresses[i] = addr
+		default:
+			return false, newTypeError(position, "can't compare type '%T' with type '%T'", left, right)
+		}
+	}
+
+	switch token {
+	case EQUAL:
+		return addresses[0] == addresses[1], nil
+	case NOT_EQUAL:
+		return addresses[0] != addresses[1], nil
+	default:
+		return false, newTypeError(position, "type 'IP' only supports the EQUAL, NOT_EQUAL and IN operators")
+	}
+}
+
+// addressInNetwork returns the IP address held by the string item, and the network held by the list
+// element, as a prefix or in its string form, when both parse. Strings that aren't addresses and
+// networks are then compared as strings.
+func addressInNetwork(item, element interface{}) (netip.Addr, netip.Prefix, bool) {
+
+	s, ok := item.(string)
+	if !ok {
+		return netip.Addr{}, netip.Prefix{}, false
+	}
+
+	network, ok := element.(netip.Prefix)
+	if n, isString := element.(string); isString && strings.Contains(n, "/") {
+		prefix, err := parseCIDR(n)
+		network, ok = prefix, err == nil
+	}
+	if !ok {
+		return netip.Addr{}, netip.Prefix{}, false
+	}
+
+	addr, err := parseIP(s)
+	if err != nil {
+		return netip.Addr{}, netip.Prefix{}, false
+	}
+
+	return addr, network, true
+}
+
+// networkContains reports whether the network contains the IP address. The network is either a
+// prefix, a single address, or the string form of one of them.
+func networkContains(network interface{}, addr netip.Addr, position int) (interface{}, error) {
+
+	switch n := network.(type) {
+	case netip.Prefix:
+		return n.Contains(addr), nil
+
+	case netip.Addr:
+		return n == addr, nil
+
+	case string:
+		if strings.Contains(n, "/") {
+			prefix, err := parseCIDR(n)
+			if err != nil {
+				return false, newTypeError(position, "malformed network %q", n)
+			}
+			return prefix.Contains(addr), nil
+		}
+		other, err := parseIP(n)
+		if err != nil {
+			return false, newTypeError(position, "malformed IP address %q", n)
+		}
+		return other == addr, nil
+
+	default:
+		return false, newTypeError(position, "can't look for an IP address in type '%T'", network)
+	}
+}
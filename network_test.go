@@ -0,0 +1,176 @@
+package boule
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestNetwork(t *testing.T) {
+
+	evaluate := func(t *testing.T, expression string, clientIP interface{}) bool {
+		evaluate, err := NewExpression(expression)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("client_ip", clientIP))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		return result
+	}
+
+	t.Run("IPv4 address in CIDR", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip in cidr'10.0.0.0/8'`, net.ParseIP("10.1.2.3")))
+		assert.True(t, evaluate(t, `client_ip in cidr'10.0.0.0/8'`, net.IPv4(10, 255, 255, 255).To4()))
+		assert.False(t, evaluate(t, `client_ip in cidr'10.0.0.0/8'`, net.ParseIP("11.0.0.1")))
+	})
+
+	t.Run("IPv4-mapped IPv6 address in IPv4 CIDR", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip in cidr'192.168.0.0/16'`, netip.MustParseAddr("::ffff:192.168.1.1")))
+	})
+
+	t.Run("IPv4-mapped CIDR", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip in cidr'::ffff:10.0.0.0/104'`, net.ParseIP("10.1.2.3")))
+
+		_, err := NewExpression(`client_ip in cidr'::ffff:0:0/80'`)
+		assert.ErrorContains(t, err, "prefix length of at least 96")
+	})
+
+	t.Run("IPv6 address in CIDR", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip in cidr'2001:db8::/32'`, netip.MustParseAddr("2001:db8:1::1")))
+		assert.False(t, evaluate(t, `client_ip in cidr'2001:db8::/32'`, netip.MustParseAddr("2001:db9::1")))
+		assert.False(t, evaluate(t, `client_ip in cidr'0.0.0.0/0'`, netip.MustParseAddr("2001:db9::1")))
+	})
+
+	t.Run("IP address in list of networks and addresses", func(t *testing.T) {
+		expression := `client_ip in ['192.168.0.0/16', '172.16.0.0/12', ip'8.8.8.8', '1.1.1.1']`
+		assert.True(t, evaluate(t, expression, net.ParseIP("172.20.0.1")))
+		assert.True(t, evaluate(t, expression, net.ParseIP("8.8.8.8")))
+		assert.True(t, evaluate(t, expression, net.ParseIP("1.1.1.1")))
+		assert.False(t, evaluate(t, expression, net.ParseIP("172.32.0.1")))
+	})
+
+	t.Run("IP address compares with IP address and string", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip == ip'10.0.0.1'`, net.ParseIP("10.0.0.1")))
+		assert.True(t, evaluate(t, `client_ip != '10.0.0.2'`, net.ParseIP("10.0.0.1")))
+		assert.True(t, evaluate(t, `'::1' == client_ip`, net.ParseIP("::1")))
+	})
+
+	t.Run("IP address given as a string", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip in cidr'10.0.0.0/8'`, "10.1.2.3"))
+		assert.False(t, evaluate(t, `client_ip in cidr'10.0.0.0/8'`, "11.0.0.1"))
+		assert.True(t, evaluate(t, `client_ip in ip'::1'`, "::1"))
+
+		expression := `client_ip in ['192.168.0.0/16', cidr'172.16.0.0/12', ip'8.8.8.8', '1.1.1.1']`
+		assert.True(t, evaluate(t, expression, "192.168.4.2"))
+		assert.True(t, evaluate(t, expression, "172.20.0.1"))
+		assert.True(t, evaluate(t, expression, "8.8.8.8"))
+		assert.True(t, evaluate(t, expression, "1.1.1.1"))
+		assert.False(t, evaluate(t, expression, "10.0.0.1"))
+
+		assert.True(t, evaluate(t, `client_ip in ['eu/west', 'us/east']`, "eu/west"))
+	})
+
+	t.Run("IP address given as a string in JSON data", func(t *testing.T) {
+		var schema Schema
+		assert.NoError(t, json.Unmarshal([]byte(`{"type": "object", "properties": {"client_ip": {"type": "string", "format": "ipv4"}}}`), &schema))
+
+		evaluate, err := NewExpression(`client_ip in cidr'10.0.0.0/8' || client_ip in ['192.168.0.0/16']`, WithSchema(&schema))
+		assert.NoError(t, err)
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(`{"client_ip": "192.168.1.1"}`), &payload))
+		data := NewData()
+		assert.NoError(t, data.AddMap(payload))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+
+	t.Run("membership in list of other types", func(t *testing.T) {
+		assert.True(t, evaluate(t, `client_ip in [1, 2, 3]`, 2))
+		assert.False(t, evaluate(t, `client_ip in []`, 2))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, test := range []struct {
+			expression string
+			clientIP   interface{}
+		}{
+			{`client_ip in ['not a network']`, net.ParseIP("10.0.0.1")},
+			{`client_ip in cidr'10.0.0.0/8'`, "Mars"},
+			{`client_ip in 'Mars'`, "Mars"},
+			{`client_ip > ip'10.0.0.1'`, net.ParseIP("10.0.0.1")},
+			{`client_ip in ['Mars']`, 3},
+		} {
+			evaluate, err := NewExpression(test.expression)
+			assert.NoError(t, err)
+
+			data := NewData()
+			assert.NoError(t, data.AddKeyValue("client_ip", test.clientIP))
+
+			_, err = evaluate(data)
+			assert.Error(t, err, test.expression)
+		}
+	})
+}
+
+func TestData_Network(t *testing.T) {
+
+	t.Run("normalizes IP addresses and networks", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("ip", net.ParseIP("10.0.0.1")))
+		assert.NoError(t, data.AddKeyValue("mapped", netip.MustParseAddr("::ffff:10.0.0.2")))
+		_, network, _ := net.ParseCIDR("10.1.2.3/16")
+		assert.NoError(t, data.AddKeyValue("network", network))
+		assert.NoError(t, data.AddKeyValue("tags", []interface{}{"eu", net.ParseIP("10.0.0.3")}))
+
+		value, err := data.Find("ip")
+		if assert.NoError(t, err) {
+			assert.Equal(t, netip.MustParseAddr("10.0.0.1"), value)
+		}
+		value, err = data.Find("mapped")
+		if assert.NoError(t, err) {
+			assert.Equal(t, netip.MustParseAddr("10.0.0.2"), value)
+		}
+		value, err = data.Find("network")
+		if assert.NoError(t, err) {
+			assert.Equal(t, netip.MustParsePrefix("10.1.0.0/16"), value)
+		}
+		value, err = data.Find("tags")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []interface{}{"eu", netip.MustParseAddr("10.0.0.3")}, value)
+		}
+	})
+
+	t.Run("IP fields are supported", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddStruct(struct {
+			ClientIP net.IP     `json:"client_ip"`
+			ServerIP netip.Addr `json:"server_ip"`
+		}{
+			ClientIP: net.ParseIP("192.168.1.1"),
+			ServerIP: netip.MustParseAddr("::1"),
+		}))
+
+		value, err := data.Find("client_ip")
+		if assert.NoError(t, err) {
+			assert.Equal(t, netip.MustParseAddr("192.168.1.1"), value)
+		}
+		value, err = data.Find("server_ip")
+		if assert.NoError(t, err) {
+			assert.Equal(t, netip.MustParseAddr("::1"), value)
+		}
+	})
+
+	t.Run("rejects invalid IP address", func(t *testing.T) {
+		assert.Error(t, NewData().AddKeyValue("ip", net.IP{1, 2, 3}))
+		assert.Error(t, NewData().AddKeyValue("ip", netip.Addr{}))
+		assert.Error(t, NewData().AddKeyValue("network", netip.Prefix{}))
+		assert.Error(t, NewData().AddKeyValue("network", (*net.IPNet)(nil)))
+	})
+}
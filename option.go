@@ -0,0 +1,124 @@
+package boule
+
+import (
+	"fmt"
+	"math"
+)
+
+// Option configures how an expression is parsed and evaluated.
+type Option func(*options)
+
+type options struct {
+	warningHandler func(Warning)
+	decimal        bool
+	loose          bool
+	tolerance      *tolerance
+	compare        comparator
+	schema         *Schema
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	o.compare = compare
+	if o.tolerance != nil {
+		o.compare = tolerant(*o.tolerance, o.compare)
+	}
+	if o.loose {
+		o.compare = loosen(o.compare)
+	}
+
+	return o
+}
+
+// comparator returns the comparison used by the evaluation, which depends on the loose mode and
+// on the tolerance.
+func (o *options) comparator() comparator {
+	if o.compare == nil {
+		return compare
+	}
+	return o.compare
+}
+
+// approximation returns the tolerance of the APPROX operator.
+func (o *options) approximation() tolerance {
+	if o.tolerance == nil {
+		return defaultTolerance
+	}
+	return *o.tolerance
+}
+
+// WithWarningHandler registers a function called with every Warning raised while the
+// expression is evaluated. Warnings are dropped when no handler is registered.
+func WithWarningHandler(handler func(Warning)) Option {
+	return func(o *options) {
+		o.warningHandler = handler
+	}
+}
+
+// WithSchema type-checks the expression against the schema when it is compiled: every identifier
+// must be declared in the schema, the operands of every operator must be of the types the operator
+// supports, constants compared with enumerated identifiers must be among their values, and the
+// expression must be of type bool. All the errors of the expression are reported at once, as an
+// ErrorList when there are more than one. In loose mode, where operands are coerced, only the
+// identifiers are checked.
+//
+// At evaluation, the optional identifiers of the schema that are missing from the data are null.
+func WithSchema(schema *Schema) Option {
+	return func(o *options) {
+		o.schema = schema
+	}
+}
+
+// WithDecimal enables the exact decimal mode, in which decimal literals such as 280.32 are read as
+// exact *big.Rat values instead of float64, so that comparing them never suffers from binary rounding.
+//
+// Binary floating-point values of the data, float64 and *big.Float, are read as the shortest
+// decimal that rounds to them at their precision, so float64(0.1) and big.NewFloat(0.1) both equal
+// the literal 0.1.
+func WithDecimal() Option {
+	return func(o *options) {
+		o.decimal = true
+	}
+}
+
+// WithLooseTypes enables the loose mode, in which comparisons coerce operands of different types
+// instead of failing: strings compare with numbers ('5' == 5) and booleans ('true' == true,
+// 1 == true), and null compares with any value. Operands of logical operators, and the result of
+// the expression, don't have to be booleans: null, zero, the empty string and the empty list are
+// false, and every other value is true. The strict mode is the default.
+func WithLooseTypes() Option {
+	return func(o *options) {
+		o.loose = true
+	}
+}
+
+// WithTolerance sets the tolerance of the comparisons involving a float64 operand, and of the ~~
+// operator. Two numbers are equal when their difference is at most the absolute tolerance, or at
+// most the relative tolerance times the largest magnitude of the two. Without this option,
+// comparisons are exact and ~~ uses a tolerance of 1e-9, both absolute and relative.
+func WithTolerance(absolute, relative float64) Option {
+	return func(o *options) {
+		o.tolerance = &tolerance{absolute: math.Abs(absolute), relative: math.Abs(relative)}
+	}
+}
+
+// Warning describes a non-fatal issue found while evaluating an expression.
+type Warning struct {
+	Message  string
+	Position int
+}
+
+// String returns the warning message along with its position in the expression.
+func (w Warning) String() string {
+	return fmt.Sprintf("%s (position=%d)", w.Message, w.Position)
+}
+
+func (o *options) warn(position int, format string, args ...interface{}) {
+	if o.warningHandler != nil {
+		o.warningHandler(Warning{Message: fmt.Sprintf(format, args...), Position: position})
+	}
+}
@@ -0,0 +1,118 @@
+package boule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Params holds the values bound to the placeholders of an expression at evaluation time. Params
+// are kept apart from Data, so that the data an expression is evaluated against can never
+// override them.
+type Params struct {
+	values map[string]interface{}
+}
+
+// NewParams returns an empty set of parameters.
+func NewParams() *Params {
+	return &Params{values: make(map[string]interface{})}
+}
+
+// Set binds a value to a named placeholder, e.g. "threshold" for `:threshold`.
+// Supported value types are the same as for Data.
+func (p *Params) Set(name string, value interface{}) error {
+	return p.set(":"+name, value)
+}
+
+// SetIndex binds a value to a positional placeholder, e.g. 1 for `$1`.
+// Supported value types are the same as for Data.
+func (p *Params) SetIndex(index int, value interface{}) error {
+	if index < 1 {
+		return fmt.Errorf("positional parameter index must be greater than 0, got %d", index)
+	}
+	return p.set("$"+strconv.Itoa(index), value)
+}
+
+func (p *Params) set(name string, value interface{}) error {
+	value, err := normalizeValue(value)
+	if err != nil {
+		return fmt.Errorf("parameter '%s': %w", name, err)
+	}
+	p.values[name] = value
+	return nil
+}
+
+// Parameter represents a placeholder, named (`:threshold`) or positional (`$1`), whose value is
+// bound at evaluation time.
+type Parameter struct {
+	name     string
+	position int
+	end      int
+}
+
+// Evaluate returns the value bound to the placeholder.
+func (p *Parameter) Evaluate(data *Data) (interface{}, error) {
+	if params := data.environment().params; params != nil {
+		if value, ok := params.values[p.name]; ok {
+			return value, nil
+		}
+	}
+	return nil, &UnboundParameterError{Name: p.name, Pos: p.position, End: p.end}
+}
+
+// Name returns the name of the placeholder, including its sigil, e.g. ":threshold" or "$1".
+func (p *Parameter) Name() string { return p.name }
+
+// Pos returns the offset of the placeholder.
+func (p *Parameter) Pos() int { return p.position }
+
+// End returns the offset following the placeholder.
+func (p *Parameter) End() int { return p.end }
+
+// parameterType is the type a parameter is expected to have, fixed by its first typed use.
+type parameterType struct {
+	Type
+	position   int
+	occurrence *Parameter // first occurrence of the parameter in the expression
+}
+
+// checkParams verifies that every placeholder of the expression is bound to a value of the type
+// inferred from its use, or of any type in loose mode.
+func (a *AST) checkParams(params *Params) error {
+
+	names := make([]string, 0, len(a.parameters))
+	for name := range a.parameters {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return a.parameters[names[i]].occurrence.position < a.parameters[names[j]].occurrence.position
+	})
+
+	for _, name := range names {
+
+		inferred := a.parameters[name]
+
+		var value interface{}
+		var ok bool
+		if params != nil {
+			value, ok = params.values[name]
+		}
+		if !ok {
+			occurrence := inferred.occurrence
+			return &UnboundParameterError{Name: name, Pos: occurrence.position, End: occurrence.end}
+		}
+
+		switch actual := typeOfValue(value); {
+		case inferred.Type == TypeAny || a.options.loose:
+		case inferred.Type == typeOrdered && actual.ordered():
+		case inferred.Type != actual:
+			return &TypeError{
+				Msg: fmt.Sprintf("parameter '%s' must be of type '%s', got '%T'", name, inferred.Type, value),
+				Pos: inferred.position,
+				End: inferred.position + len(name),
+			}
+		}
+	}
+
+	return nil
+}
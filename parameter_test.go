@@ -0,0 +1,143 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewParameterizedExpression(t *testing.T) {
+
+	t.Run("lexes named and positional placeholders", func(t *testing.T) {
+
+		lexer := newLexer(`traveltime > :threshold && destination == $12`)
+
+		var output []LexerToken
+		for token := lexer.Yield(); token.token != EOF; token = lexer.Yield() {
+			output = append(output, token.LexerToken)
+		}
+
+		assert.Equal(t, []LexerToken{
+			{token: IDENT, value: "traveltime"},
+			{token: GREATER, value: ">"},
+			{token: PARAM, value: ":threshold"},
+			{token: AND, value: "&&"},
+			{token: IDENT, value: "destination"},
+			{token: EQUAL, value: "=="},
+			{token: PARAM, value: "$12"},
+		}, output)
+	})
+
+	t.Run("same expression evaluates with different parameter sets", func(t *testing.T) {
+
+		evaluate, err := NewParameterizedExpression(`traveltime > :threshold && destination == $1`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{
+			"traveltime":  50000,
+			"destination": "Titan",
+		}))
+
+		for threshold, expected := range map[int]bool{10000: true, 90000: false} {
+			params := NewParams()
+			assert.NoError(t, params.Set("threshold", threshold))
+			assert.NoError(t, params.SetIndex(1, "Titan"))
+
+			result, err := evaluate(data, params)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, result)
+		}
+	})
+
+	t.Run("data can't override parameters", func(t *testing.T) {
+
+		evaluate, err := NewParameterizedExpression(`speed > :limit`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{
+			"speed": 50,
+			"limit": 10,
+		}))
+
+		params := NewParams()
+		assert.NoError(t, params.Set("limit", 100))
+
+		result, err := evaluate(data, params)
+		assert.NoError(t, err)
+		assert.False(t, result)
+	})
+
+	t.Run("rejects unbound parameter", func(t *testing.T) {
+
+		evaluate, err := NewParameterizedExpression(`speed > :limit`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", 50))
+
+		var unbound *UnboundParameterError
+
+		_, err = evaluate(data, NewParams())
+		if assert.True(t, errors.As(err, &unbound), err) {
+			assert.Equal(t, ":limit", unbound.Name)
+			assert.Equal(t, 8, unbound.Pos)
+			assert.Equal(t, 14, unbound.End)
+		}
+
+		expression := MustCompile(`speed > :limit`)
+		_, err = expression.Evaluate(data)
+		assert.True(t, errors.As(err, &unbound), err)
+	})
+
+	t.Run("rejects placeholders at compile time without parameters", func(t *testing.T) {
+
+		_, err := NewExpression(`speed > $1 && origin == :planet`)
+
+		var unbound *UnboundParameterError
+		if assert.True(t, errors.As(err, &unbound), err) {
+			assert.Equal(t, "$1", unbound.Name)
+			assert.Equal(t, 8, unbound.Pos)
+			assert.Equal(t, 10, unbound.End)
+		}
+	})
+
+	t.Run("checks parameter type against its first use", func(t *testing.T) {
+
+		evaluate, err := NewParameterizedExpression(`origin == :planet && speed > $1 && !:cancelled`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{
+			"origin": "Mars",
+			"speed":  50,
+		}))
+
+		params := NewParams()
+		assert.NoError(t, params.Set("planet", "Mars"))
+		assert.NoError(t, params.SetIndex(1, "fast"))
+		assert.NoError(t, params.Set("cancelled", false))
+
+		_, err = evaluate(data, params)
+		assert.Error(t, err)
+
+		assert.NoError(t, params.SetIndex(1, 10))
+
+		result, err := evaluate(data, params)
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+
+	t.Run("rejects conflicting parameter uses", func(t *testing.T) {
+		_, err := NewParameterizedExpression(`speed > :limit && name == 'x' || :limit == true`)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects malformed placeholders", func(t *testing.T) {
+		for _, input := range []string{`speed > :1`, `speed > $a`, `speed > $0`, `speed > :`} {
+			_, err := NewParameterizedExpression(input)
+			assert.Error(t, err, input)
+		}
+	})
+}
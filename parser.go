@@ -1,26 +1,104 @@
 package boule
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"math/big"
 )
 
 // AST holds the parsed expression tree and the parser state.
 type AST struct {
-	program Node
-	lexer   *lexer
-	current *lexerTokenWithPosition
-	peek    *lexerTokenWithPosition
+	source     string
+	program    Node
+	options    *options
+	parameters map[string]*parameterType
+	noIn       bool
+	lexer      *lexer
+	previous   *lexerTokenWithPosition
+	current    *lexerTokenWithPosition
+	peek       *lexerTokenWithPosition
+	pushed     *lexerTokenWithPosition // token moved back by unread, to be yielded again
+	errors     []error
 }
 
 // NewExpression parses a boolean expression string and returns an evaluator function.
 // The returned function can be called repeatedly with different Data to evaluate the
-// same expression against different variable sets.
-func NewExpression(input string) (func(data *Data) (bool, error), error) {
+// same expression against different variable sets. It is equivalent to the Evaluate method of the
+// Expression returned by Compile.
+//
+// Since the returned function can't bind placeholders, expressions containing placeholders are
+// rejected with an UnboundParameterError: use NewParameterizedExpression for them.
+func NewExpression(input string, opts ...Option) (func(data *Data) (bool, error), error) {
+
+	expression, err := Compile(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = expression.ast.checkParams(nil); err != nil {
+		return nil, err
+	}
+
+	return expression.Evaluate, nil
+}
+
+// NewParameterizedExpression parses a boolean expression string containing placeholders, named
+// (`:threshold`) or positional (`$1`), and returns an evaluator function. The returned function
+// binds the placeholders to the given Params for the duration of one evaluation, so the same
+// expression can be reused with different parameter sets. It is equivalent to the
+// EvaluateWithParams method of the Expression returned by Compile.
+//
+// The type of each placeholder is inferred from its first typed use in the expression, and the
+// bound values are checked against it before evaluation.
+func NewParameterizedExpression(input string, opts ...Option) (func(data *Data, params *Params) (bool, error), error) {
+
+	expression, err := Compile(input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return expression.EvaluateWithParams, nil
+}
+
+func (a *AST) evaluate(data *Data, params *Params) (bool, error) {
+
+	result, err := a.program.Evaluate(data.with(&environment{options: a.options, params: params}))
+	if err != nil {
+		return false, err
+	}
+
+	if a.options.loose {
+		result = truthy(result)
+	}
+
+	resultBoolean, ok := result.(bool)
+	if !ok {
+		return false, &TypeError{Msg: "can't evaluate non-boolean expression", Pos: a.program.Pos(), End: a.program.End()}
+	}
+	return resultBoolean, nil
+}
+
+// Parse parses an expression string and returns its syntax tree, for tools that inspect expressions
+// rather than evaluate them. The tree is rooted at Root, and can be traversed with Walk or Inspect.
+//
+// The whole input must be a single expression. Parsing recovers from syntax errors to report all
+// the errors of the expression at once, as an ErrorList when there are more than one.
+func Parse(input string, opts ...Option) (*AST, error) {
+	return parse(input, newOptions(opts))
+}
+
+// Root returns the root node of the syntax tree.
+func (a *AST) Root() Node {
+	return a.program
+}
+
+func parse(input string, options *options) (*AST, error) {
 
 	ast := &AST{
-		lexer: newLexer(input),
+		source:     input,
+		options:    options,
+		parameters: make(map[string]*parameterType),
+		lexer:      newLexer(input),
 		current: &lexerTokenWithPosition{
 			LexerToken: LexerToken{
 				token: OPEN,
@@ -33,107 +111,284 @@ func NewExpression(input string) (func(data *Data) (bool, error), error) {
 		},
 	}
 
-	var err error
-	if err = ast.next(); err != nil {
-		return nil, err
-	}
-	if err = ast.next(); err != nil {
-		return nil, err
-	}
+	ast.lexer.decimal = options.decimal
 
-	ast.program, err = ast.expression()
-	if err != nil {
+	_ = ast.next() // moves the first token to peek
+	if err := ast.next(); err != nil {
 		return nil, err
 	}
 
-	return func(data *Data) (bool, error) {
-		result, err := ast.program.Evaluate(data)
-		if err != nil {
-			return false, err
-		}
+	ast.program = ast.expression()
+	ast.end()
+	ast.check(options.schema)
 
-		resultBoolean, ok := result.(bool)
-		if !ok {
-			return false, fmt.Errorf("can't evaluate non-boolean identifier")
-		}
-		return resultBoolean, nil
-	}, nil
+	switch len(ast.errors) {
+	case 0:
+		return ast, nil
+	case 1:
+		return nil, ast.errors[0]
+	}
+	return nil, ErrorList(ast.errors)
 }
 
+// next moves to the next token, and returns a syntax error if the expression ends before it.
 func (a *AST) next() error {
 
-	a.current = a.peek
-	a.peek = a.lexer.Yield()
+	a.previous, a.current = a.current, a.peek
+	if a.pushed != nil {
+		a.peek, a.pushed = a.pushed, nil
+	} else {
+		a.peek = a.lexer.Yield()
+	}
 
 	if a.current.token == EOF {
-		return io.EOF
+		return a.unexpected()
 	}
 
 	return nil
 }
 
-func (a *AST) expression() (Node, error) {
+// unread moves back to the previous token, the current token becoming the next one again.
+func (a *AST) unread() {
+	a.pushed, a.peek, a.current = a.peek, a.current, a.previous
+}
 
-	prefixExpression, err := a.suffixExpression()
-	if err != nil {
-		return nil, err
+// unexpected returns the syntax error for an unexpected current token.
+func (a *AST) unexpected() error {
+
+	if a.current.token == EOF {
+		return newSyntaxError(a.current, "unexpected end of expression")
+	}
+
+	if message, ok := a.current.value.(string); ok && a.current.token == ILLEGAL && message != ILLEGAL.String() {
+		return newSyntaxError(a.current, "%s", message)
 	}
 
-	if a.peek.token.BinaryOperator() {
+	return newSyntaxError(a.current, "unexpected '%s'", a.source[a.current.position:a.current.end])
+}
+
+// report records an error found while parsing, if any. An error at the same position as the
+// previous one is a consequence of it, and is dropped.
+func (a *AST) report(err error) {
+
+	if err == nil {
+		return
+	}
+
+	if n := len(a.errors); n > 0 {
+		var previous, last spanError
+		if errors.As(a.errors[n-1], &previous) && errors.As(err, &last) {
+			p, _ := previous.span()
+			l, _ := last.span()
+			if p == l {
+				return
+			}
+		}
+	}
+
+	a.errors = append(a.errors, err)
+}
+
+// recover reports a syntax error at the current token, and skips the tokens up to one that parsing
+// can resume from. It returns a placeholder for the sub-expression that failed to parse.
+func (a *AST) recover(err error) Node {
+
+	a.report(err)
+
+	position := a.current.position
+	a.synchronize()
+
+	end := a.current.end
+	if end < position {
+		end = position
+	}
+
+	return &badExpression{position: position, end: end}
+}
+
+// expected reports that the next token isn't the one expected, and recovers from it.
+func (a *AST) expected(message string) Node {
+	err := newSyntaxError(a.peek, "%s", message)
+	_ = a.next()
+	return a.recover(err)
+}
+
+// synchronize skips the tokens following a syntax error at the current token, up to a token that
+// parsing can resume from, which is left as the next token: a boolean operator, IN, a closing
+// delimiter, a comma or the end of the expression. Delimited sub-expressions are skipped whole.
+func (a *AST) synchronize() {
+
+	if a.current.token == EOF || resumable(a.current.token) {
+		a.unread()
+		return
+	}
+
+	depth := 0
+	if a.current.token == OPEN || a.current.token == OPEN_LIST {
+		depth++
+	}
+
+	for a.peek.token != EOF && (depth > 0 || !resumable(a.peek.token)) {
+		switch a.peek.token {
+		case OPEN, OPEN_LIST:
+			depth++
+		case CLOSE, CLOSE_LIST:
+			depth--
+		}
+		_ = a.next()
+	}
+}
+
+func resumable(token Token) bool {
+	return token.BooleanOperator() || token == IN || token == CLOSE || token == CLOSE_LIST || token == COMMA
+}
+
+// end reports the tokens following the expression, which must span the whole input. Parsing
+// resumes after the next boolean operator, to report the errors in the rest of the input too.
+func (a *AST) end() {
+
+	for a.peek.token != EOF {
+
+		_ = a.next()
+		a.report(a.unexpected())
+
+		for a.peek.token != EOF && !a.peek.token.BooleanOperator() {
+			_ = a.next()
+		}
+		if a.peek.token == EOF {
+			return
+		}
+
+		_ = a.next()
+		if err := a.next(); err != nil {
+			a.report(err)
+			return
+		}
+		a.expression()
+	}
+}
+
+func (a *AST) expression() Node {
+	return a.binary(OR.Precedence())
+}
+
+// binary parses a chain of binary operations whose operators bind at least as tightly as the
+// given precedence. Boolean operators are right-associative, and the others left-associative.
+func (a *AST) binary(precedence int) Node {
+
+	left := a.operand()
+
+	for {
 
 		token := a.peek.token
 		position := a.peek.position
 
-		if err = a.next(); err != nil {
-			return nil, err
+		if !token.BinaryOperator() || token.Precedence() < precedence || (token == IN && a.noIn) {
+			return left
 		}
 
-		if err = a.next(); err != nil {
-			return nil, err
+		_ = a.next()
+
+		if err := a.next(); err != nil {
+			return a.recover(err)
 		}
 
-		suffixExpression, err := a.suffixExpression()
-		if err != nil {
-			return nil, err
+		if token == IS {
+			left = a.typeTest(left, position)
+			continue
+		}
+
+		next := token.Precedence() + 1
+		if token.BooleanOperator() {
+			next = token.Precedence()
+		}
+		right := a.binary(next)
+
+		switch token {
+		case BETWEEN:
+			left = a.between(left, position, right)
+			continue
+
+		case RANGE, RANGE_EXCLUSIVE:
+			if a.peek.token == RANGE || a.peek.token == RANGE_EXCLUSIVE {
+				a.report(newSyntaxError(a.peek, "range bound can't be a range"))
+			}
+			left = &RangeExpression{
+				low:       left,
+				position:  position,
+				exclusive: token == RANGE_EXCLUSIVE,
+				high:      right,
+			}
+			continue
+
+		case LIKE, GLOB:
+			left = a.match(left, token, position, right)
+			continue
 		}
 
 		binaryExpression := &BinaryExpression{
-			left:     prefixExpression,
+			left:     left,
 			token:    token,
 			position: position,
-			right:    suffixExpression,
+			right:    right,
 		}
 
-		if a.peek.token.BooleanOperator() {
+		left = binaryExpression
+	}
+}
 
-			token = a.peek.token
-			position = a.peek.position
+// operand parses the operand of a binary operator, recovering from its syntax errors.
+func (a *AST) operand() Node {
 
-			if err = a.next(); err != nil {
-				return nil, err
-			}
+	node, err := a.suffixExpression()
+	if err != nil {
+		return a.recover(err)
+	}
 
-			if err = a.next(); err != nil {
-				return nil, err
-			}
+	return node
+}
 
-			expression, err := a.expression()
-			if err != nil {
-				return nil, err
-			}
+// between parses the high bound of a BETWEEN expression, the low bound having been parsed already.
+func (a *AST) between(value Node, position int, low Node) Node {
 
-			return &BinaryExpression{
-				left:     binaryExpression,
-				token:    token,
-				position: position,
-				right:    expression,
-			}, nil
-		}
+	if a.peek.token != BETWEEN_AND {
+		return a.expected("expected 'and' after the low bound of 'between'")
+	}
 
-		return binaryExpression, nil
+	_ = a.next()
+
+	if err := a.next(); err != nil {
+		return a.recover(err)
 	}
 
-	return prefixExpression, nil
+	high := a.binary(BETWEEN.Precedence() + 1)
+
+	return &BetweenExpression{
+		value:    value,
+		position: position,
+		low:      low,
+		high:     high,
+	}
+}
+
+// match builds a LIKE or GLOB expression, compiling the pattern when it is known before evaluation.
+func (a *AST) match(value Node, token Token, position int, pattern Node) Node {
+
+	matchExpression := &MatchExpression{
+		value:    value,
+		token:    token,
+		position: position,
+		pattern:  pattern,
+	}
+
+	if isConstant(pattern) {
+		literal, _ := pattern.Evaluate(nil) // constant nodes don't fail
+		compiled, err := compilePattern(token, literal, pattern)
+		a.report(locate(err, matchExpression, token))
+		matchExpression.compiled = compiled
+	}
+
+	return matchExpression
 }
 
 func (a *AST) suffixExpression() (Node, error) {
@@ -141,34 +396,80 @@ func (a *AST) suffixExpression() (Node, error) {
 	var expression Node
 	var err error
 
+	if a.current.token.Keyword() && !(a.current.token == LET && a.peek.token == IDENT) {
+		return &LiteralIdent{ // a keyword where an operand is expected is an identifier
+			identifier: a.current.token.String(),
+			position:   a.current.position,
+			end:        a.current.end,
+		}, nil
+	}
+
 	if a.current.token.Literal() {
 
 		switch a.current.token {
 		case INTEGER:
 			return &LiteralInteger{
 				value:    a.current.value.(*big.Int),
+				raw:      a.source[a.current.position:a.current.end],
 				position: a.current.position,
+				end:      a.current.end,
 			}, nil
 		case FLOAT:
+			if decimal, ok := a.current.value.(*big.Rat); ok {
+				return &LiteralDecimal{
+					value:    decimal,
+					raw:      a.source[a.current.position:a.current.end],
+					position: a.current.position,
+					end:      a.current.end,
+				}, nil
+			}
 			return &LiteralFloat{
 				value:    a.current.value.(float64),
+				raw:      a.source[a.current.position:a.current.end],
 				position: a.current.position,
+				end:      a.current.end,
 			}, nil
 		case STRING:
 			return &LiteralString{
 				value:    a.current.value.(string),
 				position: a.current.position,
+				end:      a.current.end,
+			}, nil
+		case IP:
+			value, err := parseIP(a.current.value.(string))
+			if err != nil {
+				return a.bad(newSyntaxError(a.current, "malformed IP address literal")), nil
+			}
+			return &LiteralIP{
+				value:    value,
+				position: a.current.position,
+				end:      a.current.end,
+			}, nil
+		case CIDR:
+			value, err := parseCIDR(a.current.value.(string))
+			if err != nil {
+				return a.bad(newSyntaxError(a.current, "malformed CIDR literal: %v", err)), nil
+			}
+			return &LiteralCIDR{
+				value:    value,
+				position: a.current.position,
+				end:      a.current.end,
 			}, nil
 		default:
 
 			valueString, ok := a.current.value.(string)
 			if !ok {
-				return nil, fmt.Errorf("invalid syntax: raw identifier is not of type 'string' (position=%d)", a.current.position)
+				return nil, newSyntaxError(a.current, "raw identifier is not of type 'string'")
+			}
+
+			if a.peek.token == OPEN {
+				return a.call()
 			}
 
 			return &LiteralIdent{
 				identifier: valueString,
 				position:   a.current.position,
+				end:        a.current.end,
 			}, nil
 		}
 	}
@@ -192,27 +493,53 @@ func (a *AST) suffixExpression() (Node, error) {
 		}, nil
 	}
 
-	if a.current.token == OPEN {
+	if a.current.token == PARAM {
 
-		position := a.current.position
+		name := a.current.value.(string)
 
-		if err = a.next(); err != nil {
-			return nil, err
+		parameter := &Parameter{
+			name:     name,
+			position: a.current.position,
+			end:      a.current.end,
 		}
 
-		expression, err = a.expression()
-		if err != nil {
-			return nil, err
+		if _, ok := a.parameters[name]; !ok {
+			a.parameters[name] = &parameterType{occurrence: parameter}
 		}
 
+		return parameter, nil
+	}
+
+	if a.current.token == LET {
+		return a.let()
+	}
+
+	if a.current.token == OPEN_LIST {
+		return a.list()
+	}
+
+	if a.current.token == OPEN {
+
+		position := a.current.position
+
 		if err = a.next(); err != nil {
 			return nil, err
 		}
 
-		if a.current.token != CLOSE {
-			return nil, fmt.Errorf("invalid syntax: group expression not closed (position=%d)", a.current.position)
+		noIn := a.noIn
+		a.noIn = false
+		expression = a.expression()
+		a.noIn = noIn
+
+		if a.peek.token != CLOSE {
+			a.expected("group expression not closed")
+			if a.peek.token != CLOSE {
+				return &badExpression{position: position, end: a.current.end}, nil
+			}
 		}
 
+		_ = a.next()
+
 		return &GroupingExpression{
 			openPosition:  position,
 			Node:          expression,
@@ -220,5 +547,295 @@ func (a *AST) suffixExpression() (Node, error) {
 		}, nil
 	}
 
-	return nil, fmt.Errorf("invalid suffix expression (position=%d)", a.current.position)
+	if a.current.token == ILLEGAL && a.current.value != ILLEGAL.String() {
+		return a.bad(a.unexpected()), nil // malformed literal
+	}
+
+	return nil, a.unexpected()
 }
+
+// bad reports an error in the current token, which is replaced by a placeholder.
+func (a *AST) bad(err error) Node {
+	a.report(err)
+	return &badExpression{position: a.current.position, end: a.current.end}
+}
+
+func (a *AST) call() (Node, error) {
+
+	call := &CallExpression{
+		name:     a.current.value.(string),
+		position: a.current.position,
+	}
+
+	var ok bool
+	if call.function, ok = functions[call.name]; !ok {
+		a.report(newSyntaxError(a.current, "unknown function '%s'", call.name))
+	}
+
+	_ = a.next()
+
+	noIn := a.noIn
+	a.noIn = false
+	defer func() { a.noIn = noIn }()
+
+	for a.peek.token != CLOSE {
+
+		if err := a.next(); err != nil {
+			return nil, err
+		}
+
+		call.arguments = append(call.arguments, a.expression())
+
+		if a.peek.token == COMMA {
+			_ = a.next()
+			continue
+		}
+
+		if a.peek.token != CLOSE {
+			a.expected("function call not closed")
+			if a.peek.token == COMMA {
+				_ = a.next()
+				continue
+			}
+			if a.peek.token != CLOSE {
+				return &badExpression{position: call.position, end: a.current.end}, nil
+			}
+		}
+	}
+
+	_ = a.next()
+	call.closePosition = a.current.position
+
+	if call.function == nil {
+		return call, nil
+	}
+
+	if len(call.arguments) < call.function.minArguments ||
+		(call.function.maxArguments >= 0 && len(call.arguments) > call.function.maxArguments) {
+		a.report(&SyntaxError{
+			Msg:   fmt.Sprintf("wrong number of arguments for function '%s', got %d", call.name, len(call.arguments)),
+			Pos:   call.Pos(),
+			End:   call.End(),
+			Token: IDENT,
+		})
+		return call, nil
+	}
+
+	constant := true
+	for _, argument := range call.arguments {
+		constant = constant && isConstant(argument)
+	}
+
+	if constant {
+		arguments := make([]interface{}, 0, len(call.arguments))
+		for _, argument := range call.arguments {
+			value, _ := argument.Evaluate(nil) // constant nodes don't fail
+			arguments = append(arguments, value)
+		}
+		value, err := call.call(arguments)
+		if err != nil {
+			a.report(err)
+			return call, nil
+		}
+		call.constant, call.value = true, value
+	}
+
+	return call, nil
+}
+
+func (a *AST) list() (Node, error) {
+
+	list := &ListExpression{
+		openPosition: a.current.position,
+	}
+
+	noIn := a.noIn
+	a.noIn = false
+	defer func() { a.noIn = noIn }()
+
+	if a.peek.token == CLOSE_LIST {
+		_ = a.next()
+		list.closePosition = a.current.position
+		return list, nil
+	}
+
+	for a.current.token != CLOSE_LIST {
+
+		if err := a.next(); err != nil {
+			return nil, err
+		}
+
+		list.elements = append(list.elements, a.expression())
+
+		if a.peek.token != COMMA && a.peek.token != CLOSE_LIST {
+			a.expected("list not closed")
+			if a.peek.token != COMMA && a.peek.token != CLOSE_LIST {
+				return &badExpression{position: list.openPosition, end: a.current.end}, nil
+			}
+		}
+
+		_ = a.next()
+	}
+
+	list.closePosition = a.current.position
+
+	return list, nil
+}
+
+func (a *AST) let() (Node, error) {
+
+	let := &LetExpression{
+		position: a.current.position,
+	}
+
+	names := make(map[string]struct{})
+
+	for a.current.token != IN {
+
+		if err := a.next(); err != nil {
+			return nil, err
+		}
+
+		if a.current.token != IDENT {
+			return nil, newSyntaxError(a.current, "expected binding name in let expression")
+		}
+
+		name := a.current.value.(string)
+		position := a.current.position
+
+		if name == "true" || name == "false" {
+			a.report(newSyntaxError(a.current, "let binding can't be named after reserved keyword '%s'", name))
+		} else if _, ok := names[name]; ok {
+			a.report(newSyntaxError(a.current, "let binding '%s' declared twice", name))
+		}
+		names[name] = struct{}{}
+
+		if err := a.next(); err != nil {
+			return nil, err
+		}
+
+		if a.current.token != ASSIGN {
+			return nil, newSyntaxError(a.current, "expected '=' after let binding name")
+		}
+
+		if err := a.next(); err != nil {
+			return nil, err
+		}
+
+		// The IN operator can't appear at the top level of a binding, where it ends the binding.
+		noIn := a.noIn
+		a.noIn = true
+		value := a.expression()
+		a.noIn = noIn
+
+		let.bindings = append(let.bindings, &Binding{
+			name:     name,
+			value:    value,
+			position: position,
+			let:      let,
+			index:    len(let.bindings),
+		})
+
+		if a.peek.token != COMMA && a.peek.token != IN {
+			a.expected("expected ',' or 'in' after let binding")
+			if a.peek.token != COMMA && a.peek.token != IN {
+				return &badExpression{position: let.position, end: a.current.end}, nil
+			}
+		}
+
+		_ = a.next()
+	}
+
+	if err := a.next(); err != nil {
+		return nil, err
+	}
+
+	let.Node = a.expression()
+
+	// Inner let expressions are resolved first, so they shadow the bindings of outer ones.
+	bindings := make(map[string]*Binding, len(let.bindings))
+	for _, b := range let.bindings {
+		bindings[b.name] = b
+	}
+	Inspect(let, func(node Node) bool {
+		if ident, ok := node.(*LiteralIdent); ok && ident.binding == nil {
+			ident.binding = bindings[ident.identifier]
+		}
+		return true
+	})
+
+	a.report(checkBindingCycles(let))
+
+	return let, nil
+}
+
+// checkBindingCycles reports an error if a binding of the let expression depends on itself,
+// directly or through other bindings of the same let expression.
+func checkBindingCycles(let *LetExpression) error {
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(let.bindings))
+
+	var visit func(b *Binding) error
+	visit = func(b *Binding) error {
+
+		switch state[b.index] {
+		case visiting:
+			return &SyntaxError{
+				Msg:   fmt.Sprintf("let binding '%s' is part of a reference cycle", b.name),
+				Pos:   b.position,
+				End:   b.position + len(b.name),
+				Token: IDENT,
+			}
+		case visited:
+			return nil
+		}
+
+		state[b.index] = visiting
+
+		var err error
+		Inspect(b.value, func(node Node) bool {
+			if ident, ok := node.(*LiteralIdent); ok && ident.binding != nil && ident.binding.let == let && err == nil {
+				err = visit(ident.binding)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+
+		state[b.index] = visited
+		return nil
+	}
+
+	for _, b := range let.bindings {
+		if err := visit(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// badExpression is a placeholder for a sub-expression with syntax errors, which lets parsing go on
+// to report the errors in the rest of the expression.
+type badExpression struct {
+	position int
+	end      int
+}
+
+// Evaluate returns a syntax error, as bad expressions are never part of a parsed expression.
+func (b *badExpression) Evaluate(_ *Data) (interface{}, error) {
+	return nil, &SyntaxError{Msg: "bad expression", Pos: b.position, End: b.end}
+}
+
+// Pos returns the offset of the first token of the bad expression.
+func (b *badExpression) Pos() int { return b.position }
+
+// End returns the offset following the last token of the bad expression.
+func (b *badExpression) End() int { return b.end }
@@ -1,6 +1,7 @@
 package boule
 
 import (
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -29,3 +30,231 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_Precedence(t *testing.T) {
+
+	for _, test := range []struct {
+		expression string
+		data       map[string]interface{}
+		result     bool
+	}{
+		// AND and OR share the same precedence and group to the right
+		{`a && b || c`, map[string]interface{}{"a": false, "b": true, "c": true}, false},
+		{`false && true || true`, nil, false},
+		{`(a && b) || c`, map[string]interface{}{"a": false, "b": true, "c": true}, true},
+		{`c || a && b`, map[string]interface{}{"a": false, "b": true, "c": true}, true},
+		// comparisons bind tighter than boolean operators, on either side
+		{`a && x > 5`, map[string]interface{}{"a": true, "x": 10}, true},
+		{`x > 5 == a`, map[string]interface{}{"a": true, "x": 10}, true},
+		// bitwise operators bind tighter than comparisons
+		{`x & 4 != 0`, map[string]interface{}{"x": 6}, true},
+		{`x | 1 == 7`, map[string]interface{}{"x": 6}, true},
+		// BIT_AND binds tighter than BIT_OR
+		{`x | 1 & 0 == 6`, map[string]interface{}{"x": 6}, true},
+		// shifts and BIT_AND share the same precedence and are left-associative
+		{`x << 1 & 4 == 4`, map[string]interface{}{"x": 6}, true},
+		{`x & 3 << 1 == 4`, map[string]interface{}{"x": 6}, true},
+	} {
+		t.Run(test.expression, func(t *testing.T) {
+
+			data := NewData()
+			assert.NoError(t, data.AddMap(test.data))
+
+			evaluate, err := NewExpression(test.expression)
+			assert.NoError(t, err)
+
+			result, err := evaluate(data)
+			assert.NoError(t, err)
+			assert.Equal(t, test.result, result)
+		})
+	}
+}
+
+func TestParser_KeywordIdentifiers(t *testing.T) {
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"let": 1, "in": 2, "between": 3, "and": 4, "like": "abc", "glob": "a.go", "is": nil, "of": 50,
+	}))
+
+	for _, expression := range []string{
+		`in == 2 && let == 1`,
+		`between between 1 and and`,
+		`like like 'a%' && glob glob '*.go'`,
+		`is is null`,
+		`of > 10 && 50% of of == 25`,
+		`in in [let, in] && !(of in [in])`,
+		`let a = in in a == 2`,
+		`let a = (in in [2]) in a`,
+	} {
+		evaluate, err := NewExpression(expression)
+		if !assert.NoError(t, err, expression) {
+			continue
+		}
+		result, err := evaluate(data)
+		assert.NoError(t, err, expression)
+		assert.True(t, result, expression)
+
+		ast, err := Parse(expression)
+		if assert.NoError(t, err, expression) {
+			_, err = Parse(Format(ast.Root()))
+			assert.NoError(t, err, expression)
+		}
+	}
+}
+
+func TestLetExpression(t *testing.T) {
+
+	t.Run("binding is evaluated at most once", func(t *testing.T) {
+
+		ast, err := parse(`let heavy = cargo > 1000 in heavy && heavy || !heavy`, newOptions(nil))
+		assert.NoError(t, err)
+
+		let := ast.program.(*LetExpression)
+		counter := &countingNode{Node: let.bindings[0].value}
+		let.bindings[0].value = counter
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("cargo", 2000))
+
+		for i := 1; i <= 3; i++ {
+			result, err := ast.program.Evaluate(data.with(&environment{options: ast.options}))
+			assert.NoError(t, err)
+			assert.Equal(t, true, result)
+			assert.Equal(t, i, counter.count)
+		}
+	})
+
+	t.Run("inner binding shadows outer binding", func(t *testing.T) {
+
+		evaluate, err := NewExpression(`let a = true in let a = false in !a`)
+		assert.NoError(t, err)
+
+		result, err := evaluate(NewData())
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+
+	t.Run("binding shadows data identifier with a warning", func(t *testing.T) {
+
+		var warnings []Warning
+		evaluate, err := NewExpression(`let speed = limit > 10 in speed`, WithWarningHandler(func(w Warning) {
+			warnings = append(warnings, w)
+		}))
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{
+			"speed": 5,
+			"limit": 20,
+		}))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result)
+
+		if assert.Len(t, warnings, 1) {
+			assert.Contains(t, warnings[0].Message, "'speed'")
+		}
+	})
+
+	t.Run("rejects reference cycles", func(t *testing.T) {
+
+		for _, input := range []string{
+			`let a = a in a`,
+			`let a = b && x, b = c || y, c = !a in a`,
+			`let a = let b = a in b in a`,
+		} {
+			_, err := NewExpression(input)
+			assert.Error(t, err, input)
+		}
+	})
+
+	t.Run("rejects duplicate binding names", func(t *testing.T) {
+		_, err := NewExpression(`let a = x, a = y in a`)
+		assert.Error(t, err)
+	})
+}
+
+type countingNode struct {
+	Node
+	count int
+}
+
+func (c *countingNode) Evaluate(data *Data) (interface{}, error) {
+	c.count++
+	return c.Node.Evaluate(data)
+}
+
+func TestParser_Recovery(t *testing.T) {
+
+	t.Run("requires the whole input to be an expression", func(t *testing.T) {
+		for _, input := range []string{`reading ~ 1`, `a b`, `a == 1)`, `a,b`, `(a) (b)`} {
+			_, err := Parse(input)
+
+			var syntaxError *SyntaxError
+			assert.True(t, errors.As(err, &syntaxError), "%s: %v", input, err)
+		}
+	})
+
+	t.Run("reports empty expressions", func(t *testing.T) {
+		for _, input := range []string{``, `  `} {
+			_, err := Parse(input)
+			assert.EqualError(t, err, fmt.Sprintf("invalid syntax: unexpected end of expression (position=%d)", len(input)))
+		}
+	})
+
+	t.Run("reports every syntax error", func(t *testing.T) {
+		for _, test := range []struct {
+			input     string
+			positions []int
+		}{
+			{input: `(a == ) && b`, positions: []int{6}},
+			{input: `((a b) && c)`, positions: []int{4}},
+			{input: `a == == b && c ~ d`, positions: []int{5, 15}},
+			{input: `abc def && x)`, positions: []int{4, 12}},
+			{input: `unknown(1) && x == 0xZZ && y == ip'1.2.3' && z is text`, positions: []int{0, 19, 32, 50}},
+			{input: `a && (b || (c && ) || d`, positions: []int{17, 23}},
+			{input: `[1, 2 3] && abs(a b) && (c d)`, positions: []int{6, 18, 27}},
+			{input: `let a = 1, a = 2 in a && )`, positions: []int{11, 25}},
+			{input: `x between 1 or 2 && y == `, positions: []int{12, 25}},
+		} {
+			_, err := Parse(test.input)
+
+			var positions []int
+			var list ErrorList
+			if errors.As(err, &list) {
+				for _, err := range list {
+					positions = append(positions, err.(*SyntaxError).Pos)
+				}
+			} else if syntaxError := (*SyntaxError)(nil); errors.As(err, &syntaxError) {
+				positions = append(positions, syntaxError.Pos)
+			}
+			assert.Equal(t, test.positions, positions, "%s: %v", test.input, err)
+		}
+	})
+
+	t.Run("error list", func(t *testing.T) {
+		_, err := Parse(`a == == b && c ~ d`)
+		assert.EqualError(t, err, "invalid syntax: unexpected '==' (position=5) (and 1 more errors)")
+
+		var syntaxError *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxError)) {
+			assert.Equal(t, 5, syntaxError.Pos)
+		}
+
+		assert.Equal(t, ""+
+			"error: invalid syntax: unexpected '=='\n"+
+			" --> 1:6\n"+
+			"  |\n"+
+			"1 | a == == b && c ~ d\n"+
+			"  |      ^^\n"+
+			"\n"+
+			"error: invalid syntax: unexpected '~'\n"+
+			" --> 1:16\n"+
+			"  |\n"+
+			"1 | a == == b && c ~ d\n"+
+			"  |                ^\n",
+			RenderError(`a == == b && c ~ d`, err))
+	})
+}
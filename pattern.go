@@ -0,0 +1,191 @@
+package boule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MatchExpression represents a wildcard match of a string against a pattern, either SQL-style with
+// the LIKE operator (`name like 'Sat%'`) or shell-style with the GLOB operator
+// (`host glob '*.eu.internal'`). Literal patterns are compiled once, when the expression is parsed.
+type MatchExpression struct {
+	value    Node
+	token    Token
+	position int
+	pattern  Node
+	compiled *regexp.Regexp
+}
+
+// Evaluate reports whether the whole string matches the pattern.
+func (m *MatchExpression) Evaluate(data *Data) (interface{}, error) {
+
+	value, err := m.value.Evaluate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return false, locate(newTypeError(m.position, "operator '%s' expects a string on its left side, got type '%T'", m.token, value), m, m.token)
+	}
+
+	compiled := m.compiled
+	if compiled == nil {
+		pattern, err := m.pattern.Evaluate(data)
+		if err != nil {
+			return nil, err
+		}
+		if compiled, err = compilePattern(m.token, pattern, m.pattern); err != nil {
+			return nil, locate(err, m, m.token)
+		}
+	}
+
+	return compiled.MatchString(s), nil
+}
+
+// Operator returns the LIKE or GLOB token.
+func (m *MatchExpression) Operator() Token { return m.token }
+
+// Value returns the matched expression.
+func (m *MatchExpression) Value() Node { return m.value }
+
+// Pattern returns the pattern expression.
+func (m *MatchExpression) Pattern() Node { return m.pattern }
+
+// Pos returns the offset of the matched expression.
+func (m *MatchExpression) Pos() int { return m.value.Pos() }
+
+// End returns the offset following the pattern.
+func (m *MatchExpression) End() int { return m.pattern.End() }
+
+// compilePattern compiles a LIKE or GLOB pattern into an anchored regular expression.
+// Malformed patterns are reported as syntax errors spanning the pattern node.
+func compilePattern(token Token, pattern interface{}, node Node) (*regexp.Regexp, error) {
+
+	s, ok := pattern.(string)
+	if !ok {
+		return nil, newTypeError(node.Pos(), "operator '%s' expects a string pattern, got type '%T'", token, pattern)
+	}
+
+	var expression string
+	var err error
+	if token == LIKE {
+		expression, err = translateLike(s)
+	} else {
+		expression, err = translateGlob(s)
+	}
+	if err != nil {
+		return nil, &SyntaxError{Msg: fmt.Sprintf("malformed pattern %q: %v", s, err), Pos: node.Pos(), End: node.End(), Token: STRING}
+	}
+
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, &SyntaxError{Msg: fmt.Sprintf("malformed pattern %q", s), Pos: node.Pos(), End: node.End(), Token: STRING}
+	}
+
+	return compiled, nil
+}
+
+// translateLike translates a LIKE pattern, in which '%' matches any sequence of characters and '_'
+// matches a single character, into a regular expression. A backslash escapes the next character.
+func translateLike(pattern string) (string, error) {
+
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%':
+			b.WriteString(`.*`)
+		case '_':
+			b.WriteString(`.`)
+		case '\\':
+			if i++; i == len(pattern) {
+				return "", fmt.Errorf("trailing escape character")
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	b.WriteString(`$`)
+	return b.String(), nil
+}
+
+// translateGlob translates a GLOB pattern into a regular expression. As in the shell, '*' matches any
+// sequence of characters other than '/', '?' matches a single character other than '/', and
+// '[...]' matches a character of a class, negated by a leading '!' or '^'. A backslash escapes the
+// next character.
+func translateGlob(pattern string) (string, error) {
+
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(`[^/]*`)
+		case '?':
+			b.WriteString(`[^/]`)
+		case '[':
+			end, err := translateGlobClass(&b, pattern, i)
+			if err != nil {
+				return "", err
+			}
+			i = end
+		case '\\':
+			if i++; i == len(pattern) {
+				return "", fmt.Errorf("trailing escape character")
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	b.WriteString(`$`)
+	return b.String(), nil
+}
+
+// translateGlobClass translates the character class starting at the given index, and returns the
+// index of its closing bracket. A closing bracket right after the opening one is part of the class.
+func translateGlobClass(b *strings.Builder, pattern string, start int) (int, error) {
+
+	i := start + 1
+
+	negated := i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^')
+	if negated {
+		i++
+	}
+
+	end := i
+	if end < len(pattern) && pattern[end] == ']' {
+		end++
+	}
+	for end < len(pattern) && pattern[end] != ']' {
+		end++
+	}
+	if end == len(pattern) {
+		return 0, fmt.Errorf("unterminated character class")
+	}
+
+	if negated {
+		b.WriteString(`[^/`)
+	} else {
+		b.WriteString(`[`)
+	}
+	for ; i < end; i++ {
+		switch c := pattern[i]; c {
+		case '\\', '[', ']', '^':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteString(`]`)
+
+	return end, nil
+}
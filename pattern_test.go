@@ -0,0 +1,108 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPattern(t *testing.T) {
+
+	match := func(t *testing.T, expression string, value string) bool {
+		evaluate, err := NewExpression(expression)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("value", value))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		return result
+	}
+
+	t.Run("like wildcards", func(t *testing.T) {
+		for _, test := range []struct {
+			pattern  string
+			value    string
+			expected bool
+		}{
+			{`'Sat%'`, "Saturn", true},
+			{`'Sat%'`, "saturn", false},
+			{`'%urn'`, "Saturn", true},
+			{`'S_turn'`, "Saturn", true},
+			{`'S_turn'`, "Sturn", false},
+			{`'%'`, "", true},
+			{`'100\%'`, "100%", true},
+			{`'100\%'`, "1000", false},
+			{`'a.c'`, "abc", false},
+			{`'line%'`, "line\nbreak", true},
+		} {
+			assert.Equal(t, test.expected, match(t, `value like `+test.pattern, test.value), test.pattern)
+		}
+	})
+
+	t.Run("glob wildcards", func(t *testing.T) {
+		for _, test := range []struct {
+			pattern  string
+			value    string
+			expected bool
+		}{
+			{`'*.eu.internal'`, "api.eu.internal", true},
+			{`'*.eu.internal'`, "api.us.internal", false},
+			{`'*.eu.internal'`, "eu.internal", false},
+			{`'/static/*.css'`, "/static/main.css", true},
+			{`'/static/*.css'`, "/static/theme/main.css", false},
+			{`'node-?'`, "node-7", true},
+			{`'node-?'`, "node-12", false},
+			{`'node-[0-4]'`, "node-3", true},
+			{`'node-[!0-4]'`, "node-5", true},
+			{`'node-[^0-4]'`, "node-3", false},
+			{`'[]a]'`, "]", true},
+			{`'\*'`, "*", true},
+			{`'\*'`, "a", false},
+		} {
+			assert.Equal(t, test.expected, match(t, `value glob `+test.pattern, test.value), test.pattern)
+		}
+	})
+
+	t.Run("pattern from data", func(t *testing.T) {
+		evaluate, err := NewExpression(`host glob rule && host like prefix`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(map[string]interface{}{
+			"host":   "api.eu.internal",
+			"rule":   "*.eu.*",
+			"prefix": "api.%",
+		}))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result)
+
+		assert.NoError(t, data.AddKeyValue("rule", "[a-"))
+		_, err = evaluate(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("literal patterns are compiled at parse time", func(t *testing.T) {
+		_, err := NewExpression(`host glob '[a-'`)
+		assert.Error(t, err)
+
+		_, err = NewExpression(`host like 'abc\'`)
+		assert.Error(t, err)
+
+		_, err = NewExpression(`host glob 42`)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects non-string values", func(t *testing.T) {
+		evaluate, err := NewExpression(`speed like '1%'`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", 100))
+
+		_, err = evaluate(data)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+package boule
+
+// BetweenExpression represents an inclusive range check, e.g. `speed between 10 and 20`.
+type BetweenExpression struct {
+	value    Node
+	position int
+	low      Node
+	high     Node
+}
+
+// Evaluate reports whether the value is greater than or equal to the low bound, and less than or
+// equal to the high bound.
+func (b *BetweenExpression) Evaluate(data *Data) (interface{}, error) {
+
+	value, err := b.value.Evaluate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := evaluateRange(data, b.low, b.high, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := inRange(value, r, b.position, data.environment().options.comparator())
+	return result, locate(err, b, BETWEEN)
+}
+
+// Value returns the expression whose value is checked.
+func (b *BetweenExpression) Value() Node { return b.value }
+
+// Low returns the low bound.
+func (b *BetweenExpression) Low() Node { return b.low }
+
+// High returns the high bound.
+func (b *BetweenExpression) High() Node { return b.high }
+
+// Pos returns the offset of the checked expression.
+func (b *BetweenExpression) Pos() int { return b.value.Pos() }
+
+// End returns the offset following the high bound.
+func (b *BetweenExpression) End() int { return b.high.End() }
+
+// RangeExpression represents a range literal, either closed (`100..200`) or half-open
+// (`100..<200`), used on the right side of the IN operator.
+type RangeExpression struct {
+	low       Node
+	position  int
+	exclusive bool
+	high      Node
+}
+
+// Evaluate returns the range bounded by the evaluated low and high bounds.
+func (r *RangeExpression) Evaluate(data *Data) (interface{}, error) {
+	return evaluateRange(data, r.low, r.high, r.exclusive)
+}
+
+// Low returns the low bound.
+func (r *RangeExpression) Low() Node { return r.low }
+
+// High returns the high bound.
+func (r *RangeExpression) High() Node { return r.high }
+
+// Exclusive reports whether the high bound is excluded from the range.
+func (r *RangeExpression) Exclusive() bool { return r.exclusive }
+
+// Pos returns the offset of the low bound.
+func (r *RangeExpression) Pos() int { return r.low.Pos() }
+
+// End returns the offset following the high bound.
+func (r *RangeExpression) End() int { return r.high.End() }
+
+// valueRange is the value of a range literal. The high bound is excluded from half-open ranges.
+type valueRange struct {
+	low       interface{}
+	high      interface{}
+	exclusive bool
+}
+
+func evaluateRange(data *Data, lowNode, highNode Node, exclusive bool) (valueRange, error) {
+
+	low, err := lowNode.Evaluate(data)
+	if err != nil {
+		return valueRange{}, err
+	}
+
+	high, err := highNode.Evaluate(data)
+	if err != nil {
+		return valueRange{}, err
+	}
+
+	return valueRange{low: low, high: high, exclusive: exclusive}, nil
+}
+
+// inRange reports whether the value lies within the range, using the ordering of the comparison
+// operators: numbers, strings, times and versions.
+func inRange(value interface{}, r valueRange, position int, compare comparator) (interface{}, error) {
+
+	aboveLow, err := compare(value, r.low, GREATER_OR_EQUAL, position)
+	if err != nil {
+		return false, err
+	}
+
+	highToken := LESS_OR_EQUAL
+	if r.exclusive {
+		highToken = LESS
+	}
+
+	belowHigh, err := compare(value, r.high, highToken, position)
+	if err != nil {
+		return false, err
+	}
+
+	return aboveLow.(bool) && belowHigh.(bool), nil
+}
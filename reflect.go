@@ -0,0 +1,118 @@
+package boule
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"reflect"
+	"time"
+
+	"github.com/victordeleau/boule/internal/prefixtree"
+)
+
+// goType is the type of the values of a supported Go type, and the function returning its values
+// in the form expressions evaluate against.
+type goType struct {
+	Type
+	normalize func(value interface{}) (interface{}, error)
+}
+
+// goTypes are the Go types of the supported values that are not typed by their kind. Along with
+// goKinds, they are the only list of the supported Go types, for Data, AddStruct and SchemaFor.
+var goTypes = map[reflect.Type]goType{
+	reflect.TypeOf((*big.Int)(nil)):   {TypeNumber, normalizeNumber},
+	reflect.TypeOf((*big.Rat)(nil)):   {TypeNumber, normalizeNumber},
+	reflect.TypeOf((*big.Float)(nil)): {TypeNumber, normalizeNumber},
+	reflect.TypeOf(ByteSize(0)):       {TypeNumber, normalizeUnit},
+	reflect.TypeOf(Percent(0)):        {TypeNumber, normalizeUnit},
+	reflect.TypeOf(netip.Addr{}):      {TypeIP, normalizeNetwork},
+	reflect.TypeOf(net.IP(nil)):       {TypeIP, normalizeNetwork},
+	reflect.TypeOf(netip.Prefix{}):    {TypeNetwork, normalizeNetwork},
+	reflect.TypeOf((*net.IPNet)(nil)): {TypeNetwork, normalizeNetwork},
+	reflect.TypeOf(Version{}):         {TypeVersion, asIs},
+	reflect.TypeOf(time.Time{}):       {TypeTime, asIs},
+}
+
+// goKinds are the kinds of the supported values typed by their kind, and the predeclared type the
+// values of the kind are stored as, so that the values of named types such as `type Speed int`
+// are stored as the predeclared type.
+var goKinds = map[reflect.Kind]struct {
+	Type
+	goType reflect.Type
+}{
+	reflect.Bool:    {TypeBool, reflect.TypeOf(false)},
+	reflect.String:  {TypeString, reflect.TypeOf("")},
+	reflect.Int:     {TypeNumber, reflect.TypeOf(int(0))},
+	reflect.Int8:    {TypeNumber, reflect.TypeOf(int8(0))},
+	reflect.Int16:   {TypeNumber, reflect.TypeOf(int16(0))},
+	reflect.Int32:   {TypeNumber, reflect.TypeOf(int32(0))},
+	reflect.Int64:   {TypeNumber, reflect.TypeOf(int64(0))},
+	reflect.Uint:    {TypeNumber, reflect.TypeOf(uint(0))},
+	reflect.Uint8:   {TypeNumber, reflect.TypeOf(uint8(0))},
+	reflect.Uint16:  {TypeNumber, reflect.TypeOf(uint16(0))},
+	reflect.Uint32:  {TypeNumber, reflect.TypeOf(uint32(0))},
+	reflect.Uint64:  {TypeNumber, reflect.TypeOf(uint64(0))},
+	reflect.Float32: {TypeNumber, reflect.TypeOf(float32(0))},
+	reflect.Float64: {TypeNumber, reflect.TypeOf(float64(0))},
+}
+
+// isValueType reports whether values of the type are supported as is, rather than being structs
+// to walk into, or slices to store as lists.
+func isValueType(t reflect.Type) bool {
+	if _, ok := goTypes[t]; ok {
+		return true
+	}
+	_, ok := goKinds[t.Kind()]
+	return ok
+}
+
+// SchemaFor derives a schema from a struct type, declaring the identifiers that AddStruct adds to
+// Data for a value of the type: fields are named after their json tag, fields without json tag are
+// ignored, and the fields of nested structs are named in dot notation, e.g. "owner.name". Map
+// fields are ignored.
+//
+// Pointer fields, fields tagged omitempty and the fields of structs reached through a pointer are
+// optional, and so are slice fields, declared as lists, which AddStruct skips when nil. Slices of
+// structs, maps or slices, which AddStruct skips, are ignored. Every other field is required.
+func SchemaFor(t reflect.Type) (*Schema, error) {
+
+	if t == nil {
+		return nil, fmt.Errorf("can't derive a schema from a nil type")
+	}
+
+	fields, err := prefixtree.StructFields(t, isValueType)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := NewSchema()
+	for _, field := range fields {
+		if err = schema.add(Field{Name: field.Key, Type: typeOfGo(field.Type), Required: !field.Optional}); err != nil {
+			return nil, fmt.Errorf("type %s: %w", t, err)
+		}
+	}
+
+	return schema, nil
+}
+
+// SchemaOf is like SchemaFor, for the struct type T.
+func SchemaOf[T any]() (*Schema, error) {
+	return SchemaFor(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// typeOfGo returns the type of the values of a Go type, TypeAny for interfaces.
+func typeOfGo(t reflect.Type) Type {
+
+	if typ, ok := goTypes[t]; ok {
+		return typ.Type
+	}
+	if kind, ok := goKinds[t.Kind()]; ok {
+		return kind.Type
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return TypeList
+	}
+
+	return TypeAny
+}
@@ -0,0 +1,147 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchemaOf(t *testing.T) {
+
+	type Owner struct {
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+	}
+
+	type Ship struct {
+		Destination string            `json:"destination"`
+		Speed       float64           `json:"speed"`
+		Crew        uint8             `json:"crew"`
+		Mass        *big.Rat          `json:"mass"`
+		Active      bool              `json:"active"`
+		Release     Version           `json:"release"`
+		Launch      time.Time         `json:"launch"`
+		Docked      *time.Time        `json:"docked"`
+		Address     netip.Addr        `json:"address"`
+		Network     netip.Prefix      `json:"network"`
+		Tags        []string          `json:"tags"`
+		Payload     interface{}       `json:"payload"`
+		Owner       Owner             `json:"owner"`
+		Captain     *Owner            `json:"captain"`
+		Crews       []Owner           `json:"crews"`
+		Index       map[string]string `json:"index"`
+		Ignored     string            `json:"-"`
+		Untagged    string
+	}
+
+	t.Run("derives identifiers from json tags", func(t *testing.T) {
+		schema, err := SchemaOf[Ship]()
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Field{
+				{Name: "active", Type: TypeBool, Required: true},
+				{Name: "address", Type: TypeIP, Required: true},
+				{Name: "captain.email", Type: TypeString},
+				{Name: "captain.name", Type: TypeString},
+				{Name: "crew", Type: TypeNumber, Required: true},
+				{Name: "destination", Type: TypeString, Required: true},
+				{Name: "docked", Type: TypeTime},
+				{Name: "launch", Type: TypeTime, Required: true},
+				{Name: "mass", Type: TypeNumber},
+				{Name: "network", Type: TypeNetwork, Required: true},
+				{Name: "owner.email", Type: TypeString},
+				{Name: "owner.name", Type: TypeString, Required: true},
+				{Name: "payload", Type: TypeAny, Required: true},
+				{Name: "release", Type: TypeVersion, Required: true},
+				{Name: "speed", Type: TypeNumber, Required: true},
+				{Name: "tags", Type: TypeList},
+			}, schema.Fields())
+		}
+	})
+
+	t.Run("accepts pointers to structs", func(t *testing.T) {
+		schema, err := SchemaFor(reflect.TypeOf(&Owner{}))
+		if assert.NoError(t, err) {
+			assert.Len(t, schema.Fields(), 2)
+		}
+	})
+
+	t.Run("rejects non-struct and recursive types", func(t *testing.T) {
+		type Node struct {
+			Next *Node `json:"next"`
+		}
+
+		_, err := SchemaOf[string]()
+		assert.Error(t, err)
+		_, err = SchemaOf[Node]()
+		assert.Error(t, err)
+		_, err = SchemaFor(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects invalid identifier names", func(t *testing.T) {
+		_, err := SchemaOf[struct {
+			ClientIP string `json:"client-ip"`
+		}]()
+		assert.Error(t, err)
+	})
+
+	t.Run("type-checks expressions against the data of the struct", func(t *testing.T) {
+		schema, err := SchemaOf[Ship]()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = Compile(`destination > 3`, WithSchema(schema))
+		var typeError *TypeError
+		assert.True(t, errors.As(err, &typeError), err)
+
+		expression, err := Compile(`destination == 'Mars' && speed > 3 && owner.name == 'Ada'`, WithSchema(schema))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		data := NewData()
+		assert.NoError(t, data.AddStruct(Ship{
+			Destination: "Mars",
+			Speed:       4.5,
+			Address:     netip.MustParseAddr("10.0.0.1"),
+			Network:     netip.MustParsePrefix("10.0.0.0/8"),
+			Owner:       Owner{Name: "Ada"},
+		}))
+
+		result, err := expression.Evaluate(data)
+		if assert.NoError(t, err) {
+			assert.True(t, result)
+		}
+	})
+
+	t.Run("evaluates the lists of slice fields", func(t *testing.T) {
+		schema, err := SchemaOf[Ship]()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expression, err := Compile(`'radio' in tags && len(tags) == 2 && destination == 'Mars'`, WithSchema(schema))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		data := NewData()
+		assert.NoError(t, data.AddStruct(Ship{
+			Destination: "Mars",
+			Address:     netip.MustParseAddr("10.0.0.1"),
+			Network:     netip.MustParsePrefix("10.0.0.0/8"),
+			Tags:        []string{"radio", "cargo"},
+			Crews:       []Owner{{Name: "Ada"}},
+		}))
+
+		result, err := expression.Evaluate(data)
+		if assert.NoError(t, err) {
+			assert.True(t, result)
+		}
+	})
+}
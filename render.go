@@ -0,0 +1,146 @@
+package boule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// spanError is implemented by the errors carrying the span of the offending part of an expression.
+type spanError interface {
+	error
+	span() (pos, end int)
+	message() string
+}
+
+func (e *SyntaxError) span() (int, int)              { return e.Pos, e.End }
+func (e *TypeError) span() (int, int)                { return e.Pos, e.End }
+func (e *UnknownIdentifierError) span() (int, int)   { return e.Pos, e.End }
+func (e *AmbiguousIdentifierError) span() (int, int) { return e.Pos, e.End }
+func (e *UnboundParameterError) span() (int, int)    { return e.Pos, e.End }
+
+func (e *SyntaxError) message() string { return "invalid syntax: " + e.Msg }
+func (e *TypeError) message() string   { return e.Msg }
+func (e *UnknownIdentifierError) message() string {
+	return fmt.Sprintf("unknown identifier '%s'", e.Name)
+}
+func (e *AmbiguousIdentifierError) message() string {
+	return fmt.Sprintf("ambiguous identifier '%s'", e.Name)
+}
+func (e *UnboundParameterError) message() string {
+	return fmt.Sprintf("parameter '%s' is not bound", e.Name)
+}
+
+// ANSI escape sequences used by RenderErrorANSI.
+const (
+	ansiError  = "\x1b[1;31m"
+	ansiGutter = "\x1b[1;34m"
+	ansiReset  = "\x1b[0m"
+)
+
+// RenderError renders an error returned for the expression source as a multi-line diagnostic: the
+// message, the line and column of the error, and the offending line with the span of the error
+// underlined by carets. Lines and columns start at 1, columns counting characters.
+//
+//	error: invalid syntax: unexpected ')'
+//	 --> 1:9
+//	  |
+//	1 | speed > )
+//	  |         ^
+//
+// Errors that carry no span are rendered as their message only, and the errors of an ErrorList one
+// after the other, separated by an empty line.
+func RenderError(source string, err error) string {
+	return renderError(source, err, false)
+}
+
+// RenderErrorANSI is like RenderError, but colours the diagnostic with ANSI escape sequences for
+// display in a terminal.
+func RenderErrorANSI(source string, err error) string {
+	return renderError(source, err, true)
+}
+
+func renderError(source string, err error, ansi bool) string {
+
+	var list ErrorList
+	if errors.As(err, &list) {
+		diagnostics := make([]string, 0, len(list))
+		for _, err := range list {
+			diagnostics = append(diagnostics, renderError(source, err, ansi))
+		}
+		return strings.Join(diagnostics, "\n")
+	}
+
+	paint := func(s, colour string) string {
+		if !ansi {
+			return s
+		}
+		return colour + s + ansiReset
+	}
+
+	var b strings.Builder
+
+	var spanned spanError
+	if !errors.As(err, &spanned) {
+		fmt.Fprintf(&b, "%s %s\n", paint("error:", ansiError), err)
+		return b.String()
+	}
+
+	pos, end := spanned.span()
+	pos, end = clamp(pos, 0, len(source)), clamp(end, 0, len(source))
+	line, column, start := lineColumn(source, pos)
+
+	text := source[start:]
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimSuffix(text, "\r")
+
+	// The underline stops at the end of the line for spans over several lines, and is one caret
+	// wide for empty spans, e.g. at the end of the expression. It is one caret per character.
+	from := clamp(pos-start, 0, len(text))
+	width := utf8.RuneCountInString(text[from:clamp(end-start, from, len(text))])
+	if width < 1 {
+		width = 1
+	}
+
+	// Tabs are kept in the indentation of the underline so that it lines up with the offending line,
+	// every other character being replaced by a space.
+	var indent strings.Builder
+	for _, c := range text[:from] {
+		if c == '\t' {
+			indent.WriteRune(c)
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+
+	number := fmt.Sprint(line)
+	gutter := strings.Repeat(" ", len(number))
+
+	fmt.Fprintf(&b, "%s %s\n", paint("error:", ansiError), spanned.message())
+	fmt.Fprintf(&b, "%s%s %d:%d\n", gutter, paint("-->", ansiGutter), line, column)
+	fmt.Fprintf(&b, "%s %s\n", gutter, paint("|", ansiGutter))
+	fmt.Fprintf(&b, "%s %s\n", paint(number+" |", ansiGutter), text)
+	fmt.Fprintf(&b, "%s %s%s\n", paint(gutter+" |", ansiGutter), indent.String(), paint(strings.Repeat("^", width), ansiError))
+
+	return b.String()
+}
+
+// lineColumn returns the line and column of a byte offset in the source, both starting at 1, the
+// column counting characters, and the byte offset of the start of the line.
+func lineColumn(source string, offset int) (line, column, start int) {
+	start = strings.LastIndexByte(source[:offset], '\n') + 1
+	return strings.Count(source[:offset], "\n") + 1, utf8.RuneCountInString(source[start:offset]) + 1, start
+}
+
+func clamp(value, low, high int) int {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
@@ -0,0 +1,97 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderError(t *testing.T) {
+
+	t.Run("underlines the span of a syntax error", func(t *testing.T) {
+		source := `speed > )`
+		_, err := Compile(source)
+		assert.Equal(t, ""+
+			"error: invalid syntax: unexpected ')'\n"+
+			" --> 1:9\n"+
+			"  |\n"+
+			"1 | speed > )\n"+
+			"  |         ^\n",
+			RenderError(source, err))
+	})
+
+	t.Run("counts characters rather than bytes", func(t *testing.T) {
+		source := `name == 'Ærø' && speed > )`
+		_, err := Compile(source)
+		assert.Equal(t, ""+
+			"error: invalid syntax: unexpected ')'\n"+
+			" --> 1:26\n"+
+			"  |\n"+
+			"1 | name == 'Ærø' && speed > )\n"+
+			"  |                          ^\n",
+			RenderError(source, err))
+
+		assert.Contains(t, RenderError(`'Ærø' == 1 && ø`, &TypeError{Msg: "mismatch", Pos: 0, End: 12}), ""+
+			"1 | 'Ærø' == 1 && ø\n"+
+			"  | ^^^^^^^^^^\n")
+	})
+
+	t.Run("renders the offending line of multi-line expressions", func(t *testing.T) {
+		source := "speed > 10 &&\n\tdestination == 'Titan' ||\n\tcancelled"
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", 20))
+
+		expression := MustCompile(source)
+		_, err := expression.Evaluate(data)
+		assert.Equal(t, ""+
+			"error: unknown identifier 'destination'\n"+
+			" --> 2:2\n"+
+			"  |\n"+
+			"2 | \tdestination == 'Titan' ||\n"+
+			"  | \t^^^^^^^^^^^\n",
+			RenderError(source, err))
+	})
+
+	t.Run("underlines spans over several lines up to the end of the line", func(t *testing.T) {
+		source := "speed >\n'fast'"
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("speed", 20))
+
+		_, err := MustCompile(source).Evaluate(data)
+		assert.Equal(t, ""+
+			"error: can't compare type 'int' with type 'string'\n"+
+			" --> 1:1\n"+
+			"  |\n"+
+			"1 | speed >\n"+
+			"  | ^^^^^^^\n",
+			RenderError(source, err))
+	})
+
+	t.Run("places a caret at the end of truncated expressions", func(t *testing.T) {
+		source := "speed >"
+		_, err := Compile(source)
+		assert.Equal(t, ""+
+			"error: invalid syntax: unexpected end of expression\n"+
+			" --> 1:8\n"+
+			"  |\n"+
+			"1 | speed >\n"+
+			"  |        ^\n",
+			RenderError(source, err))
+	})
+
+	t.Run("renders errors without span as their message", func(t *testing.T) {
+		assert.Equal(t, "error: boom\n", RenderError("speed > 10", errors.New("boom")))
+	})
+
+	t.Run("colours the diagnostic", func(t *testing.T) {
+		source := `speed > )`
+		_, err := Compile(source)
+		assert.Equal(t, ""+
+			"\x1b[1;31merror:\x1b[0m invalid syntax: unexpected ')'\n"+
+			" \x1b[1;34m-->\x1b[0m 1:9\n"+
+			"  \x1b[1;34m|\x1b[0m\n"+
+			"\x1b[1;34m1 |\x1b[0m speed > )\n"+
+			"\x1b[1;34m  |\x1b[0m         \x1b[1;31m^\x1b[0m\n",
+			RenderErrorANSI(source, err))
+	})
+}
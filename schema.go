@@ -0,0 +1,191 @@
+package boule
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/victordeleau/boule/internal/prefixtree"
+)
+
+// Type is the type of the value of an identifier, as declared in a Schema, or of an expression.
+type Type int
+
+const (
+	TypeAny     Type = iota // any value, not type-checked
+	TypeBool                // true or false
+	TypeString              // a string
+	TypeNumber              // an integer, a float or a decimal
+	TypeVersion             // a semantic version
+	TypeTime                // a date and time
+	TypeIP                  // an IP address
+	TypeNetwork             // an IP network
+	TypeList                // a list of values
+	typeOrdered             // any type supporting the LESS and GREATER operators
+)
+
+var typeNames = map[Type]string{
+	TypeAny:     "any",
+	TypeBool:    "bool",
+	TypeString:  "string",
+	TypeNumber:  "number",
+	TypeVersion: "version",
+	TypeTime:    "time",
+	TypeIP:      "ip",
+	TypeNetwork: "network",
+	TypeList:    "list",
+	typeOrdered: "ordered",
+}
+
+func (t Type) String() string {
+	return typeNames[t]
+}
+
+func (t Type) ordered() bool {
+	return t == TypeNumber || t == TypeString || t == TypeVersion || t == TypeTime || t == typeOrdered
+}
+
+func typeOfValue(value interface{}) Type {
+	switch value.(type) {
+	case bool:
+		return TypeBool
+	case string:
+		return TypeString
+	case Version:
+		return TypeVersion
+	case time.Time:
+		return TypeTime
+	case netip.Addr:
+		return TypeIP
+	case netip.Prefix:
+		return TypeNetwork
+	case []interface{}:
+		return TypeList
+	case *big.Rat:
+		return TypeNumber
+	}
+	if _, _, _, kind := toNumeric(value); kind != numNone {
+		return TypeNumber
+	}
+	return TypeAny
+}
+
+// Schema declares the identifiers an expression may reference, and the type of their values. An
+// expression compiled with WithSchema is type-checked against its schema, so that references to
+// undeclared identifiers and operands of the wrong type are rejected before any Data exists.
+type Schema struct {
+	fields map[string]Field
+	tree   prefixtree.Tree
+}
+
+// Field is an identifier declared in a Schema.
+type Field struct {
+	Name     string
+	Type     Type
+	Required bool          // whether the data must provide the identifier
+	Enum     []interface{} // values the identifier is restricted to, any value of its type if empty
+}
+
+// NewSchema returns an empty schema ready for identifier declaration via Add, Require and Declare.
+// The zero Schema is also an empty schema.
+func NewSchema() *Schema {
+	return &Schema{fields: make(map[string]Field)}
+}
+
+// Add declares an optional identifier. The data may omit an optional identifier, which then
+// evaluates to null. Names follow the same rules as the keys of Data, e.g. "owner.name".
+func (s *Schema) Add(name string, t Type) error {
+	return s.add(Field{Name: name, Type: t})
+}
+
+// Require declares a required identifier, which the data must provide.
+func (s *Schema) Require(name string, t Type) error {
+	return s.add(Field{Name: name, Type: t, Required: true})
+}
+
+// Declare declares an identifier described by a field, e.g. restricted to enumerated values, which
+// compared constants must be among. Enumerated values are of the same types as the values of Data.
+func (s *Schema) Declare(field Field) error {
+	return s.add(field)
+}
+
+func (s *Schema) add(field Field) error {
+
+	if _, ok := typeNames[field.Type]; !ok || field.Type == typeOrdered {
+		return fmt.Errorf("identifier '%s': unknown type %d", field.Name, field.Type)
+	}
+
+	if _, ok := s.fields[field.Name]; ok {
+		return fmt.Errorf("identifier '%s' is already declared", field.Name)
+	}
+
+	if len(field.Enum) > 0 {
+		enum, err := normalizeValue(field.Enum)
+		if err != nil {
+			return fmt.Errorf("identifier '%s': enumerated values: %w", field.Name, err)
+		}
+		field.Enum = enum.([]interface{})
+	}
+
+	if s.fields == nil {
+		s.fields = make(map[string]Field)
+	}
+
+	if err := s.tree.Insert(field.Name, field); err != nil {
+		return err
+	}
+
+	s.fields[field.Name] = field
+	return nil
+}
+
+// Fields returns the declared identifiers, sorted by name.
+func (s *Schema) Fields() []Field {
+
+	fields := make([]Field, 0, len(s.fields))
+	for _, field := range s.fields {
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	return fields
+}
+
+// lookup returns the identifier declared under the name, which is resolved like the identifiers of
+// Data: the name may be any prefix that matches a single declared identifier.
+func (s *Schema) lookup(name string) (Field, error) {
+
+	field, err := s.tree.Find(name)
+	if err != nil {
+		return Field{}, err
+	}
+
+	return field.(Field), nil
+}
+
+// optional reports whether the name refers to an optional identifier of the schema.
+func (s *Schema) optional(name string) bool {
+
+	if s == nil {
+		return false
+	}
+
+	field, err := s.lookup(name)
+	return err == nil && !field.Required
+}
+
+// identifierError converts the error of a failed identifier lookup into the error reported for
+// the identifier.
+func identifierError(err error, l *LiteralIdent) error {
+	switch {
+	case errors.Is(err, prefixtree.ErrPrefixNotFound):
+		return &UnknownIdentifierError{Name: l.identifier, Pos: l.position, End: l.end}
+	case errors.Is(err, prefixtree.ErrPrefixAmbiguous):
+		return &AmbiguousIdentifierError{Name: l.identifier, Pos: l.position, End: l.end}
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+
+	t.Run("declares identifiers", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Require("destination", TypeString))
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+		assert.NoError(t, schema.Add("owner.name", TypeString))
+
+		assert.Equal(t, []Field{
+			{Name: "destination", Type: TypeString, Required: true},
+			{Name: "owner.name", Type: TypeString},
+			{Name: "speed", Type: TypeNumber},
+		}, schema.Fields())
+	})
+
+	t.Run("rejects invalid declarations", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+
+		assert.Error(t, schema.Add("speed", TypeString))
+		assert.Error(t, schema.Add("1speed", TypeNumber))
+		assert.Error(t, schema.Add("false", TypeNumber))
+		assert.Error(t, schema.Add("size", Type(42)))
+	})
+
+	t.Run("resolves identifiers by unique prefix", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+		assert.NoError(t, schema.Add("size", TypeNumber))
+
+		_, err := Compile(`spe > 3`, WithSchema(schema))
+		assert.NoError(t, err)
+
+		_, err = Compile(`s > 3`, WithSchema(schema))
+		var ambiguous *AmbiguousIdentifierError
+		assert.True(t, errors.As(err, &ambiguous), err)
+	})
+
+	t.Run("optional identifiers missing from the data are null", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Require("destination", TypeString))
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+
+		expression := MustCompile(`destination == 'Mars' && (speed is null || speed > 3)`, WithSchema(schema))
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("destination", "Mars"))
+		result, err := expression.Evaluate(data)
+		if assert.NoError(t, err) {
+			assert.True(t, result)
+		}
+
+		var unknown *UnknownIdentifierError
+		_, err = expression.Evaluate(NewData())
+		if assert.True(t, errors.As(err, &unknown), err) {
+			assert.Equal(t, "destination", unknown.Name)
+		}
+	})
+
+	t.Run("null is only equal to null", func(t *testing.T) {
+		schema := NewSchema()
+		assert.NoError(t, schema.Add("speed", TypeNumber))
+		assert.NoError(t, schema.Add("limit", TypeNumber))
+
+		missing := NewData()
+		present := NewData()
+		assert.NoError(t, present.AddKeyValue("speed", 1))
+
+		for _, test := range []struct {
+			expression string
+			missing    bool
+			present    bool
+		}{
+			{`speed == 1`, false, true},
+			{`speed != 1`, true, false},
+			{`!(speed == 1)`, true, false},
+			{`speed in [1, 2]`, false, true},
+			{`speed == limit`, true, false},
+		} {
+			expression := MustCompile(test.expression, WithSchema(schema))
+
+			result, err := expression.Evaluate(missing)
+			if assert.NoError(t, err, test.expression) {
+				assert.Equal(t, test.missing, result, test.expression)
+			}
+			result, err = expression.Evaluate(present)
+			if assert.NoError(t, err, test.expression) {
+				assert.Equal(t, test.present, result, test.expression)
+			}
+		}
+
+		_, err := MustCompile(`speed > 1`, WithSchema(schema)).Evaluate(missing)
+		var typeError *TypeError
+		assert.True(t, errors.As(err, &typeError), err)
+	})
+}
@@ -0,0 +1,319 @@
+package boule
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringArgument returns the argument at the given index, which must be a string.
+func stringArgument(arguments []interface{}, index int) (string, error) {
+	s, ok := arguments[index].(string)
+	if !ok {
+		return "", fmt.Errorf("expected argument %d of type 'string', got '%T'", index+1, arguments[index])
+	}
+	return s, nil
+}
+
+// intArgument returns the argument at the given index, which must be an integer fitting in an int.
+func intArgument(arguments []interface{}, index int) (int, error) {
+	i64, bi, _, kind := toNumeric(arguments[index])
+	switch {
+	case kind == numInt64 && i64 >= math.MinInt && i64 <= math.MaxInt:
+		return int(i64), nil
+	case kind == numBigInt && bi.IsInt64() && bi.Int64() >= math.MinInt && bi.Int64() <= math.MaxInt:
+		return int(bi.Int64()), nil
+	case kind == numInt64 || kind == numBigInt:
+		return 0, fmt.Errorf("argument %d is out of range", index+1)
+	default:
+		return 0, fmt.Errorf("expected argument %d of type 'integer', got '%T'", index+1, arguments[index])
+	}
+}
+
+func callLower(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(s), nil
+}
+
+func callUpper(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(s), nil
+}
+
+// callTrim removes the leading and trailing white space of a string, or the leading and trailing
+// characters contained in the optional cutset.
+func callTrim(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(arguments) == 1 {
+		return strings.TrimSpace(s), nil
+	}
+	cutset, err := stringArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Trim(s, cutset), nil
+}
+
+// callLen returns the number of characters of a string, or the number of elements of a list.
+func callLen(arguments []interface{}) (interface{}, error) {
+	switch a := arguments[0].(type) {
+	case string:
+		return utf8.RuneCountInString(a), nil
+	case []interface{}:
+		return len(a), nil
+	default:
+		return nil, fmt.Errorf("expected argument of type 'string' or 'list', got '%T'", a)
+	}
+}
+
+// callSubstr returns the characters of a string starting at a zero-based index, up to the optional
+// length or to the end of the string.
+func callSubstr(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, err := intArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("negative start index %d", start)
+	}
+
+	runes := []rune(s)
+	if start > len(runes) {
+		start = len(runes)
+	}
+	end := len(runes)
+
+	if len(arguments) == 3 {
+		length, err := intArgument(arguments, 2)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, fmt.Errorf("negative length %d", length)
+		}
+		if length < end-start {
+			end = start + length
+		}
+	}
+
+	return string(runes[start:end]), nil
+}
+
+func callReplace(arguments []interface{}) (interface{}, error) {
+	var s [3]string
+	for i := range s {
+		var err error
+		if s[i], err = stringArgument(arguments, i); err != nil {
+			return nil, err
+		}
+	}
+	return strings.ReplaceAll(s[0], s[1], s[2]), nil
+}
+
+// callSplit splits a string into the list of substrings separated by the separator.
+func callSplit(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := stringArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(s, separator)
+	list := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		list = append(list, part)
+	}
+	return list, nil
+}
+
+// callJoin concatenates a list of strings, placing the separator between them.
+func callJoin(arguments []interface{}) (interface{}, error) {
+	list, ok := arguments[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected argument 1 of type 'list', got '%T'", arguments[0])
+	}
+	separator, err := stringArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, 0, len(list))
+	for i, element := range list {
+		s, ok := element.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected list of strings, got '%T' at index %d", element, i)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// callIndexOf returns the zero-based character index of the first occurrence of a substring, or -1
+// if the string doesn't contain it.
+func callIndexOf(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	substring, err := stringArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	index := strings.Index(s, substring)
+	if index < 0 {
+		return -1, nil
+	}
+	return utf8.RuneCountInString(s[:index]), nil
+}
+
+// callPadLeft pads a string on its left side to the given number of characters, with spaces or with
+// the optional padding string.
+func callPadLeft(arguments []interface{}) (interface{}, error) {
+	s, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	width, err := intArgument(arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if width > maxValueSize {
+		return nil, fmt.Errorf("width %d exceeds %d characters", width, maxValueSize)
+	}
+
+	padding := " "
+	if len(arguments) == 3 {
+		if padding, err = stringArgument(arguments, 2); err != nil {
+			return nil, err
+		}
+		if padding == "" {
+			return nil, fmt.Errorf("empty padding")
+		}
+	}
+
+	missing := width - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s, nil
+	}
+
+	paddingRunes := []rune(padding)
+	pad := make([]rune, 0, missing)
+	for i := 0; i < missing; i++ {
+		pad = append(pad, paddingRunes[i%len(paddingRunes)])
+	}
+	return string(pad) + s, nil
+}
+
+// formatVerbs are the verbs of the format function, and the arguments each verb accepts.
+var formatVerbs = map[byte]func(argument interface{}) bool{
+	'v': isPrintable,
+	's': isString,
+	'q': isString,
+	't': isBool,
+	'd': isInteger,
+	'b': isInteger,
+	'o': isInteger,
+	'x': func(argument interface{}) bool { return isString(argument) || isInteger(argument) },
+	'X': func(argument interface{}) bool { return isString(argument) || isInteger(argument) },
+	'e': isNumber,
+	'E': isNumber,
+	'f': isNumber,
+	'F': isNumber,
+	'g': isNumber,
+	'G': isNumber,
+}
+
+func isString(argument interface{}) bool { _, ok := argument.(string); return ok }
+
+func isBool(argument interface{}) bool { _, ok := argument.(bool); return ok }
+
+// isPrintable reports whether the argument converts to a string, which %v formats.
+func isPrintable(argument interface{}) bool {
+	_, err := callString([]interface{}{argument})
+	return err == nil
+}
+
+func isInteger(argument interface{}) bool { _, ok := toInteger(argument); return ok }
+
+func isNumber(argument interface{}) bool {
+	_, _, _, kind := toNumeric(argument)
+	return kind != numNone || isDecimal(argument)
+}
+
+// callFormat formats its arguments according to a format specifier, following the verbs of the fmt
+// package, e.g. format('%s-%04d', region, id). Each verb must have an argument of a type it
+// accepts, and each argument a verb, so that the result never holds fmt's error markers such as
+// %!d(MISSING). Numbers formatted with a floating-point verb are converted to float64, and the
+// arguments of %v to the string string() returns.
+func callFormat(arguments []interface{}) (interface{}, error) {
+	format, err := stringArgument(arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := append([]interface{}(nil), arguments[1:]...)
+	index := 0
+
+	for i := 0; i < len(format); i++ {
+
+		if format[i] != '%' {
+			continue
+		}
+
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) >= 0 {
+			i++ // flags, width and precision
+		}
+
+		if i == len(format) {
+			return nil, fmt.Errorf("format ends with an incomplete verb")
+		}
+
+		verb := format[i]
+		if verb == '%' {
+			continue
+		}
+
+		accepts, ok := formatVerbs[verb]
+		if !ok {
+			return nil, fmt.Errorf("unsupported verb '%%%c'", verb)
+		}
+		if index == len(values) {
+			return nil, fmt.Errorf("missing argument for verb '%%%c'", verb)
+		}
+		if !accepts(values[index]) {
+			return nil, fmt.Errorf("verb '%%%c' can't format argument %d of type '%T'", verb, index+2, values[index])
+		}
+		switch {
+		case strings.IndexByte("eEfFgG", verb) >= 0:
+			values[index] = toFloat(values[index])
+		case verb == 'v':
+			values[index], _ = callString(values[index : index+1])
+		}
+		index++
+	}
+
+	if index < len(values) {
+		return nil, fmt.Errorf("%d arguments without verb", len(values)-index)
+	}
+
+	return fmt.Sprintf(format, values...), nil
+}
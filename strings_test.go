@@ -0,0 +1,95 @@
+package boule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStringFunctions(t *testing.T) {
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"name":   "  Ærøskøbing ",
+		"region": "eu",
+		"id":     42,
+		"tags":   "web,db,cache",
+		"offset": -1,
+		"width":  maxValueSize + 1,
+	}))
+
+	for _, expression := range []string{
+		`lower('ÆRØ') == 'ærø' && upper('ærø') == 'ÆRØ'`,
+		`trim(name) == 'Ærøskøbing' && trim('--x--', '-') == 'x'`,
+		`len(trim(name)) == 10 && len('') == 0 && len(split(tags, ',')) == 3`,
+		`substr('Ærøskøbing', 3) == 'skøbing' && substr('Ærøskøbing', 0, 3) == 'Ærø'`,
+		`substr('abc', 1, 10) == 'bc' && substr('abc', 5) == ''`,
+		`replace('a-b-c', '-', '.') == 'a.b.c'`,
+		`join(split(tags, ','), ';') == 'web;db;cache' && join([], ',') == ''`,
+		`'db' in split(tags, ',')`,
+		`index_of('Ærøskøbing', 'skø') == 3 && index_of('abc', 'z') < 0`,
+		`pad_left('7', 3, '0') == '007' && pad_left('7', 4, 'ab') == 'aba7' && pad_left('1234', 2) == '1234'`,
+		`pad_left('ø', 3) == '  ø'`,
+		`format('%s-%04d', region, id) == 'eu-0042' && format('none') == 'none'`,
+		`format('%.1f%%', 12) == '12.0%' && format('%x', 255) == 'ff' && format('%5.2f', 0.5) == ' 0.50'`,
+		`format('%v', true) == 'true' && format('%v-%v', id, 0.25) == '42-0.25' && format('%3v', region) == ' eu'`,
+		`upper(substr(trim(name), 0, 1)) == 'Æ'`,
+	} {
+		evaluate, err := NewExpression(expression)
+		if !assert.NoError(t, err, expression) {
+			continue
+		}
+		result, err := evaluate(data)
+		assert.NoError(t, err, expression)
+		assert.True(t, result, expression)
+	}
+
+	t.Run("rejects invalid arguments", func(t *testing.T) {
+		for _, expression := range []string{
+			`lower(1) == ''`,
+			`substr('abc', '1') == ''`,
+			`join(['a', 1], ',') == ''`,
+			`pad_left('a', 3, '') == ''`,
+			`lower('a', 'b') == ''`,
+			`replace('a', 'b') == ''`,
+			`pad_left('a', 65537) == ''`,
+			`format('%d') == ''`,
+			`format('%d', 'a') == ''`,
+			`format('%t', 1) == ''`,
+			`format('%s', 'a', 'b') == ''`,
+			`format('%y', 1) == ''`,
+			`format('%[1]s', 'a') == ''`,
+			`format('%5', 1) == ''`,
+			`format('%s', 1) == ''`,
+			`format('%v', [1]) == ''`,
+		} {
+			_, err := NewExpression(expression)
+			assert.Error(t, err, expression)
+		}
+
+		for _, expression := range []string{
+			`len(id) == 2`,
+			`format('%s', id) == '42'`,
+			`format('%s', true) == 'true'`,
+			`substr('abc', offset) == ''`,
+			`substr('abc', 0, offset) == ''`,
+		} {
+			evaluate, err := NewExpression(expression)
+			assert.NoError(t, err, expression)
+			_, err = evaluate(data)
+			assert.Error(t, err, expression)
+		}
+	})
+
+	t.Run("rejects pad widths above the cap with a type error", func(t *testing.T) {
+		expression := `pad_left(region, width) == region`
+		evaluate, err := NewExpression(expression)
+		assert.NoError(t, err)
+
+		_, err = evaluate(data)
+		var typeError *TypeError
+		if assert.True(t, errors.As(err, &typeError), "%v", err) {
+			assert.Equal(t, `pad_left(region, width)`, expression[typeError.Pos:typeError.End])
+		}
+	})
+}
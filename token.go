@@ -29,6 +29,50 @@ const (
 	// group
 	OPEN
 	CLOSE
+
+	// binding
+	LET
+	IN
+	ASSIGN
+	COMMA
+
+	// parameter
+	PARAM
+
+	// network literal
+	IP
+	CIDR
+
+	// list
+	OPEN_LIST
+	CLOSE_LIST
+
+	// bitwise operator
+	BIT_AND
+	BIT_OR
+	BIT_XOR
+	BIT_AND_NOT
+	SHIFT_LEFT
+	SHIFT_RIGHT
+
+	// range
+	BETWEEN
+	BETWEEN_AND
+	RANGE
+	RANGE_EXCLUSIVE
+
+	// pattern matching
+	LIKE
+	GLOB
+
+	// type test
+	IS
+
+	// approximate equality
+	APPROX
+
+	// percentage
+	OF
 )
 
 var tokens = map[Token]string{
@@ -59,6 +103,97 @@ var tokens = map[Token]string{
 	// group
 	OPEN:  "(",
 	CLOSE: ")",
+
+	// binding
+	LET:    "let",
+	IN:     "in",
+	ASSIGN: "=",
+	COMMA:  ",",
+
+	// parameter
+	PARAM: "PARAM",
+
+	// network literal
+	IP:   "IP",
+	CIDR: "CIDR",
+
+	// list
+	OPEN_LIST:  "[",
+	CLOSE_LIST: "]",
+
+	// bitwise operator
+	BIT_AND:     "&",
+	BIT_OR:      "|",
+	BIT_XOR:     "^",
+	BIT_AND_NOT: "&^",
+	SHIFT_LEFT:  "<<",
+	SHIFT_RIGHT: ">>",
+
+	// range
+	BETWEEN:         "between",
+	BETWEEN_AND:     "and",
+	RANGE:           "..",
+	RANGE_EXCLUSIVE: "..<",
+
+	// pattern matching
+	LIKE: "like",
+	GLOB: "glob",
+
+	// type test
+	IS: "is",
+
+	// approximate equality
+	APPROX: "~~",
+
+	// percentage
+	OF: "of",
+}
+
+// precedences of the binary operators, from the loosest to the tightest binding. AND and OR share
+// the loosest precedence and group to the right, so that `a && b || c` reads `a && (b || c)`.
+var precedences = map[Token]int{
+	OR:               1,
+	AND:              1,
+	EQUAL:            3,
+	NOT_EQUAL:        3,
+	GREATER:          3,
+	GREATER_OR_EQUAL: 3,
+	LESS:             3,
+	LESS_OR_EQUAL:    3,
+	IN:               3,
+	BETWEEN:          3,
+	LIKE:             3,
+	GLOB:             3,
+	IS:               3,
+	APPROX:           3,
+	RANGE:            4,
+	RANGE_EXCLUSIVE:  4,
+	BIT_OR:           5,
+	BIT_XOR:          5,
+	BIT_AND:          6,
+	BIT_AND_NOT:      6,
+	SHIFT_LEFT:       6,
+	SHIFT_RIGHT:      6,
+	OF:               7,
+}
+
+// typedStrings maps the prefixes of typed string literals, e.g. ip'10.0.0.1', to their token.
+var typedStrings = map[string]Token{
+	"ip":   IP,
+	"cidr": CIDR,
+}
+
+// keywords are the words read as operators. They are read as identifiers where an operand is
+// expected, e.g. `in == 1`, except let when it starts a let expression.
+var keywords = map[string]Token{
+	"let":     LET,
+	"in":      IN,
+	"between": BETWEEN,
+	"and":     BETWEEN_AND,
+	"like":    LIKE,
+	"glob":    GLOB,
+	"is":      IS,
+	"of":      OF,
 }
 
 // String returns the human-readable representation of the token.
@@ -74,14 +209,27 @@ func (t Token) Valid() bool {
 	return true
 }
 
-// Literal reports whether the token is a literal type (INTEGER, FLOAT, STRING, or IDENT).
+// Literal reports whether the token is a literal type (INTEGER, FLOAT, STRING, IDENT, IP or CIDR).
 func (t Token) Literal() bool {
-	return t > 1 && t < 6
+	return (t > 1 && t < 6) || t == IP || t == CIDR
 }
 
-// BinaryOperator reports whether the token is a binary operator (comparison or logical).
+// BinaryOperator reports whether the token is a binary operator (comparison, membership, range,
+// pattern matching, type test, approximate equality, percentage, bitwise or logical).
 func (t Token) BinaryOperator() bool {
-	return t > 5 && t < 14
+	return (t > 5 && t < 14) || t == IN || t.BitwiseOperator() || t == BETWEEN || t == RANGE || t == RANGE_EXCLUSIVE ||
+		t == LIKE || t == GLOB || t == IS || t == APPROX || t == OF
+}
+
+// BitwiseOperator reports whether the token is a bitwise operator (&, |, ^, &^, << or >>).
+func (t Token) BitwiseOperator() bool {
+	return t >= BIT_AND && t <= SHIFT_RIGHT
+}
+
+// Precedence returns the binding strength of a binary operator, higher binding tighter, or 0 for
+// any other token.
+func (t Token) Precedence() int {
+	return precedences[t]
 }
 
 // BooleanOperator reports whether the token is a boolean connective (AND or OR).
@@ -89,6 +237,12 @@ func (t Token) BooleanOperator() bool {
 	return t > 11 && t < 14
 }
 
+// Keyword reports whether the token is a keyword (let, in, between, and, like, glob, is or of).
+func (t Token) Keyword() bool {
+	keyword, ok := keywords[t.String()]
+	return ok && keyword == t
+}
+
 // UnaryOperator reports whether the token is a unary operator (NOT).
 func (t Token) UnaryOperator() bool {
 	return t == 14
@@ -96,5 +250,5 @@ func (t Token) UnaryOperator() bool {
 
 // Group reports whether the token is a grouping delimiter (OPEN or CLOSE).
 func (t Token) Group() bool {
-	return t > 14
+	return t == OPEN || t == CLOSE
 }
@@ -0,0 +1,77 @@
+package boule
+
+import (
+	"fmt"
+	"math"
+)
+
+// tolerance is the largest difference for which two floats are considered equal: the absolute
+// tolerance, or the relative tolerance scaled by the largest magnitude of the two, whichever is
+// greater.
+type tolerance struct {
+	absolute float64
+	relative float64
+}
+
+// defaultTolerance is used by the APPROX operator when no tolerance is configured.
+var defaultTolerance = tolerance{absolute: 1e-9, relative: 1e-9}
+
+func (t tolerance) equal(a, b float64) bool {
+	if a == b {
+		return true
+	}
+	difference := math.Abs(a - b)
+	return difference <= t.absolute || difference <= t.relative*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// approximate reports whether two numbers are equal within the tolerance.
+func approximate(left, right interface{}, t tolerance, position int) (interface{}, error) {
+	for _, operand := range []interface{}{left, right} {
+		if !typeTests["number"](operand) {
+			return false, newTypeError(position, "operator '~~' expects numbers, got type '%T'", operand)
+		}
+	}
+	return t.equal(toFloat(left), toFloat(right)), nil
+}
+
+// tolerant returns a comparator that considers a float equal to any number within the tolerance,
+// and compares any other operands with the given comparator.
+func tolerant(t tolerance, compare comparator) comparator {
+	return func(left, right interface{}, token Token, position int) (interface{}, error) {
+
+		isNumber := typeTests["number"]
+		_, _, _, leftKind := toNumeric(left)
+		_, _, _, rightKind := toNumeric(right)
+
+		if (leftKind == numFloat64 || rightKind == numFloat64) && isNumber(left) && isNumber(right) &&
+			t.equal(toFloat(left), toFloat(right)) {
+			switch token {
+			case EQUAL, LESS_OR_EQUAL, GREATER_OR_EQUAL:
+				return true, nil
+			case NOT_EQUAL, LESS, GREATER:
+				return false, nil
+			}
+		}
+
+		return compare(left, right, token, position)
+	}
+}
+
+// callApprox reports whether two numbers differ by at most the given absolute epsilon.
+func callApprox(arguments []interface{}) (interface{}, error) {
+
+	var values [3]interface{}
+	for i := range values {
+		var err error
+		if values[i], err = numberArgument(arguments, i); err != nil {
+			return nil, err
+		}
+	}
+
+	epsilon := toFloat(values[2])
+	if epsilon < 0 {
+		return nil, fmt.Errorf("negative epsilon")
+	}
+
+	return math.Abs(toFloat(values[0])-toFloat(values[1])) <= epsilon, nil
+}
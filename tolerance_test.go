@@ -0,0 +1,64 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestTolerance(t *testing.T) {
+
+	celsius := 21.7
+	fahrenheit := celsius*9/5 + 32 // 71.06 after rounding errors
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"reading":  (fahrenheit - 32) * 5 / 9,
+		"expected": celsius,
+		"count":    big.NewInt(3),
+		"ratio":    2.9999999999,
+	}))
+
+	evaluate := func(t *testing.T, expression string, opts ...Option) bool {
+		evaluate, err := NewExpression(expression, opts...)
+		if !assert.NoError(t, err, expression) {
+			return false
+		}
+		result, err := evaluate(data)
+		assert.NoError(t, err, expression)
+		return result
+	}
+
+	t.Run("approx operator", func(t *testing.T) {
+		assert.True(t, evaluate(t, `reading ~~ expected && reading ~~ 21.7`))
+		assert.False(t, evaluate(t, `reading ~~ 21.70001`))
+		assert.True(t, evaluate(t, `reading ~~ 21.70001`, WithTolerance(1e-4, 0)))
+		assert.True(t, evaluate(t, `ratio ~~ count`))
+	})
+
+	t.Run("approx function", func(t *testing.T) {
+		assert.True(t, evaluate(t, `approx(reading, 21.7, 0.001) && !approx(reading, 21.8, 0.01)`))
+
+		_, err := NewExpression(`approx(1.0, 1.0, 'a')`)
+		assert.Error(t, err)
+	})
+
+	t.Run("tolerance applies to float comparisons", func(t *testing.T) {
+		assert.False(t, evaluate(t, `reading == 21.7`))
+		assert.True(t, evaluate(t, `reading == 21.7 && reading >= 21.7 && !(reading < 21.7)`, WithTolerance(1e-9, 0)))
+		assert.True(t, evaluate(t, `ratio == count && count <= ratio`, WithTolerance(0, 1e-9)))
+		assert.False(t, evaluate(t, `ratio == count`, WithTolerance(0, 1e-12)))
+		assert.True(t, evaluate(t, `count == 3 && count != 4`, WithTolerance(1, 0)))
+	})
+
+	t.Run("tolerance composes with loose mode", func(t *testing.T) {
+		assert.True(t, evaluate(t, `reading == '21.7' && reading ~~ '21.7'`, WithTolerance(1e-9, 0), WithLooseTypes()))
+	})
+
+	t.Run("rejects non-numeric operands", func(t *testing.T) {
+		evaluate, err := NewExpression(`reading ~~ 'a'`)
+		assert.NoError(t, err)
+		_, err = evaluate(data)
+		assert.Error(t, err)
+	})
+}
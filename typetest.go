@@ -0,0 +1,86 @@
+package boule
+
+import "math/big"
+
+// typeTests are the type names usable on the right side of the IS operator, and the predicates
+// checking the dynamic type of a value.
+var typeTests = map[string]func(value interface{}) bool{
+	"string": func(value interface{}) bool {
+		_, ok := value.(string)
+		return ok
+	},
+	"number": func(value interface{}) bool {
+		if _, ok := value.(*big.Rat); ok {
+			return true
+		}
+		_, _, _, kind := toNumeric(value)
+		return kind != numNone
+	},
+	"bool": func(value interface{}) bool {
+		_, ok := value.(bool)
+		return ok
+	},
+	"null": func(value interface{}) bool {
+		return value == nil
+	},
+	"list": func(value interface{}) bool {
+		_, ok := value.([]interface{})
+		return ok
+	},
+}
+
+// TypeTestExpression represents a test of the dynamic type of a value, e.g. `reading is number`.
+type TypeTestExpression struct {
+	value    Node
+	position int
+	typeName string
+	end      int
+	test     func(value interface{}) bool
+}
+
+// Evaluate reports whether the value is of the tested type.
+func (t *TypeTestExpression) Evaluate(data *Data) (interface{}, error) {
+
+	value, err := t.value.Evaluate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.test(value), nil
+}
+
+// Value returns the tested expression.
+func (t *TypeTestExpression) Value() Node { return t.value }
+
+// TypeName returns the tested type: string, number, bool, null or list.
+func (t *TypeTestExpression) TypeName() string { return t.typeName }
+
+// Pos returns the offset of the tested expression.
+func (t *TypeTestExpression) Pos() int { return t.value.Pos() }
+
+// End returns the offset following the type name.
+func (t *TypeTestExpression) End() int { return t.end }
+
+// typeTest parses the type name of an IS expression, the current token being the type name.
+func (a *AST) typeTest(value Node, position int) Node {
+
+	if a.current.token != IDENT {
+		return a.recover(newSyntaxError(a.current, "expected one of the types string, number, bool, null or list after 'is'"))
+	}
+
+	name := a.current.value.(string)
+
+	test, ok := typeTests[name]
+	if !ok {
+		a.report(newSyntaxError(a.current, "expected one of the types string, number, bool, null or list after 'is'"))
+		return &badExpression{position: value.Pos(), end: a.current.end}
+	}
+
+	return &TypeTestExpression{
+		value:    value,
+		position: position,
+		typeName: name,
+		end:      a.current.end,
+		test:     test,
+	}
+}
@@ -0,0 +1,67 @@
+package boule
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTypeTest(t *testing.T) {
+
+	evaluate := func(t *testing.T, expression string, payload string) bool {
+		evaluate, err := NewExpression(expression)
+		assert.NoError(t, err)
+
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(payload), &m))
+
+		data := NewData()
+		assert.NoError(t, data.AddMap(m))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		return result
+	}
+
+	t.Run("tests the dynamic type of JSON values", func(t *testing.T) {
+		for _, test := range []struct {
+			payload string
+			number  bool
+			string  bool
+			bool    bool
+			null    bool
+			list    bool
+		}{
+			{payload: `{"reading": 21.5}`, number: true},
+			{payload: `{"reading": "21.5"}`, string: true},
+			{payload: `{"reading": false}`, bool: true},
+			{payload: `{"reading": null}`, null: true},
+			{payload: `{"reading": [21.5, "n/a", null]}`, list: true},
+		} {
+			assert.Equal(t, test.number, evaluate(t, `reading is number`, test.payload), test.payload)
+			assert.Equal(t, test.string, evaluate(t, `reading is string`, test.payload), test.payload)
+			assert.Equal(t, test.bool, evaluate(t, `reading is bool`, test.payload), test.payload)
+			assert.Equal(t, test.null, evaluate(t, `reading is null`, test.payload), test.payload)
+			assert.Equal(t, test.list, evaluate(t, `reading is list`, test.payload), test.payload)
+		}
+	})
+
+	t.Run("guards comparisons of values of varying types", func(t *testing.T) {
+		expression := `(reading is number && reading > 20) || reading is string && float(reading) > 20`
+		assert.True(t, evaluate(t, expression, `{"reading": 21.5}`))
+		assert.True(t, evaluate(t, expression, `{"reading": "21.5"}`))
+		assert.False(t, evaluate(t, expression, `{"reading": null}`))
+		assert.True(t, evaluate(t, `!(reading is null) || fallback`, `{"reading": null, "fallback": true}`))
+	})
+
+	t.Run("lists from data support membership", func(t *testing.T) {
+		assert.True(t, evaluate(t, `tags is list && 'eu' in tags && len(tags) == 2`, `{"tags": ["us", "eu"]}`))
+	})
+
+	t.Run("rejects unknown types", func(t *testing.T) {
+		for _, expression := range []string{`reading is integer`, `reading is 'string'`, `reading is`} {
+			_, err := NewExpression(expression)
+			assert.Error(t, err, expression)
+		}
+	})
+}
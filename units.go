@@ -0,0 +1,59 @@
+package boule
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/victordeleau/boule/internal/units"
+)
+
+// ByteSize is a number of bytes, e.g. ByteSize(512 << 20). Byte sizes compare with numbers and with
+// literals such as 512MB or 1.5GiB, which are read as numbers of bytes.
+type ByteSize = units.ByteSize
+
+// Percent is a percentage, e.g. Percent(20). Percentages compare as fractions, as do literals such
+// as 20%, which is read as 0.2.
+type Percent = units.Percent
+
+// ParseByteSize parses a byte size with a decimal (KB, MB, ...) or binary (KiB, MiB, ...) unit, e.g.
+// "512MB" or "1.5GiB". A number without unit is a number of bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	return units.ParseByteSize(s)
+}
+
+// normalizeUnit converts byte sizes to numbers of bytes, and percentages to fractions.
+func normalizeUnit(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case ByteSize:
+		return uint64(v), nil
+	case Percent:
+		return v.Fraction(), nil
+	}
+	return nil, fmt.Errorf("'value' type %T is not supported", value)
+}
+
+// percentOf multiplies two numbers, typically a percentage and a quantity, e.g. `10% of disk_total`.
+// The product is exact: it is a *big.Int if it is a whole number, and a float64 otherwise, unless one
+// of the operands is a decimal.
+func percentOf(left, right interface{}, position int) (interface{}, error) {
+
+	isNumber := typeTests["number"]
+
+	product := big.NewRat(1, 1)
+	for _, operand := range []interface{}{left, right} {
+		r, ok := toRat(operand)
+		if !isNumber(operand) || !ok {
+			return nil, newTypeError(position, "operator 'of' expects finite numbers, got '%v'", operand)
+		}
+		product.Mul(product, r)
+	}
+
+	if isDecimal(left) || isDecimal(right) {
+		return product, nil
+	}
+	if product.IsInt() {
+		return new(big.Int).Set(product.Num()), nil
+	}
+	f, _ := product.Float64()
+	return f, nil
+}
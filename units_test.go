@@ -0,0 +1,68 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestUnits(t *testing.T) {
+
+	data := NewData()
+	assert.NoError(t, data.AddMap(map[string]interface{}{
+		"memory":     ByteSize(768 << 20),
+		"disk_free":  uint64(40_000_000_000),
+		"disk_total": ByteSize(500_000_000_000),
+		"cpu":        Percent(85),
+		"ratio":      0.1,
+		"price":      big.NewRat(1999, 100),
+	}))
+
+	for _, expression := range []string{
+		`memory > 512MiB && memory < 1GiB && memory == 768MiB`,
+		`disk_total == 500GB && disk_free < 10% of disk_total && disk_free > 5% of disk_total`,
+		`cpu > 80% && cpu == 85% && ratio == 10%`,
+		`50% of 3 == 1.5 && 25% of 4 == 1 && 2 of 3 == 6`,
+		`10% of price < 2 && 10% of price > 1.99`,
+	} {
+		evaluate, err := NewExpression(expression)
+		if !assert.NoError(t, err, expression) {
+			continue
+		}
+		result, err := evaluate(data)
+		assert.NoError(t, err, expression)
+		assert.True(t, result, expression)
+	}
+
+	t.Run("exact in decimal mode", func(t *testing.T) {
+		evaluate, err := NewExpression(`10% of price == 1.999 && 12.5% == 0.125`, WithDecimal())
+		assert.NoError(t, err)
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+
+	t.Run("of expects numbers", func(t *testing.T) {
+		evaluate, err := NewExpression(`10% of 'disk' > 1`)
+		assert.NoError(t, err)
+		_, err = evaluate(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("parses byte sizes", func(t *testing.T) {
+		size, err := ParseByteSize("1.5GiB")
+		assert.NoError(t, err)
+		assert.Equal(t, ByteSize(1610612736), size)
+	})
+
+	t.Run("stores byte sizes as numbers of bytes and percentages as fractions", func(t *testing.T) {
+		value, err := data.Find("memory")
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint64(768<<20), value)
+		}
+		value, err = data.Find("cpu")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0.85, value)
+		}
+	})
+}
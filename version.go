@@ -0,0 +1,70 @@
+package boule
+
+import (
+	"fmt"
+
+	"github.com/victordeleau/boule/internal/semver"
+)
+
+// Version is a semantic version. Versions compare by precedence, following Semantic Versioning
+// 2.0.0: pre-release versions have a lower precedence than the associated normal version, and
+// build metadata is ignored.
+type Version = semver.Version
+
+// ParseVersion parses a semantic version of the form MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD],
+// e.g. "3.0.0-rc.1". A leading 'v' is accepted.
+func ParseVersion(s string) (Version, error) {
+	return semver.Parse(s)
+}
+
+func callSemver(arguments []interface{}) (interface{}, error) {
+	switch a := arguments[0].(type) {
+	case string:
+		return semver.Parse(a)
+	case Version:
+		return a, nil
+	default:
+		return nil, fmt.Errorf("expected argument of type 'string', got '%T'", a)
+	}
+}
+
+// compareVersion compares a semantic version with another version, which may be given in its
+// string form.
+func compareVersion(left, right interface{}, token Token, position int) (interface{}, error) {
+
+	var versions [2]Version
+
+	for i, operand := range []interface{}{left, right} {
+		switch o := operand.(type) {
+		case Version:
+			versions[i] = o
+		case string:
+			version, err := semver.Parse(o)
+			if err != nil {
+				return false, newTypeError(position, "can't compare version with malformed version %q", o)
+			}
+			versions[i] = version
+		default:
+			return false, newTypeError(position, "can't compare type '%T' with type '%T'", left, right)
+		}
+	}
+
+	c := versions[0].Compare(versions[1])
+
+	switch token {
+	case EQUAL:
+		return c == 0, nil
+	case NOT_EQUAL:
+		return c != 0, nil
+	case LESS:
+		return c < 0, nil
+	case LESS_OR_EQUAL:
+		return c <= 0, nil
+	case GREATER:
+		return c > 0, nil
+	case GREATER_OR_EQUAL:
+		return c >= 0, nil
+	default:
+		return false, newTypeError(position, "type 'version' only supports the EQUAL, NOT_EQUAL, LESS, LESS_OR_EQUAL, GREATER and GREATER_OR_EQUAL operators")
+	}
+}
@@ -0,0 +1,126 @@
+package boule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+
+	evaluate := func(t *testing.T, expression string, appVersion interface{}) bool {
+		evaluate, err := NewExpression(expression)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("app_version", appVersion))
+
+		result, err := evaluate(data)
+		assert.NoError(t, err)
+		return result
+	}
+
+	mustParse := func(s string) Version {
+		v, err := ParseVersion(s)
+		assert.NoError(t, err)
+		return v
+	}
+
+	t.Run("compatibility gate", func(t *testing.T) {
+		expression := `app_version >= semver('2.3.0') && app_version < semver('3.0.0-0')`
+		assert.True(t, evaluate(t, expression, mustParse("2.3.0")))
+		assert.True(t, evaluate(t, expression, mustParse("2.10.1")))
+		assert.False(t, evaluate(t, expression, mustParse("2.3.0-beta")))
+		assert.False(t, evaluate(t, expression, mustParse("3.0.0-alpha")))
+		assert.False(t, evaluate(t, expression, mustParse("3.0.0")))
+	})
+
+	t.Run("string data compares with version", func(t *testing.T) {
+		assert.True(t, evaluate(t, `app_version > semver('1.9.0')`, "1.10.0"))
+		assert.True(t, evaluate(t, `semver('1.9.0') < app_version`, "1.10.0"))
+		assert.True(t, evaluate(t, `app_version == semver('1.0.0+build.2')`, "1.0.0+build.1"))
+	})
+
+	t.Run("version argument from data", func(t *testing.T) {
+		assert.True(t, evaluate(t, `semver(app_version) != semver('1.0.0')`, "1.0.1"))
+	})
+
+	t.Run("rejects malformed version literal at parse time", func(t *testing.T) {
+		_, err := NewExpression(`app_version > semver('1.0')`)
+		assert.Error(t, err)
+
+		_, err = NewExpression(`app_version > semver('1.0.0', '2.0.0')`)
+		assert.Error(t, err)
+
+		_, err = NewExpression(`app_version > unknown('1.0.0')`)
+		assert.Error(t, err)
+	})
+
+	t.Run("errors at evaluation", func(t *testing.T) {
+		for _, test := range []struct {
+			expression string
+			appVersion interface{}
+		}{
+			{`app_version > semver('1.0.0')`, "not a version"},
+			{`app_version > semver('1.0.0')`, 2},
+			{`semver(app_version) > semver('1.0.0')`, 1},
+		} {
+			evaluate, err := NewExpression(test.expression)
+			assert.NoError(t, err)
+
+			data := NewData()
+			assert.NoError(t, data.AddKeyValue("app_version", test.appVersion))
+
+			_, err = evaluate(data)
+			assert.Error(t, err, test.expression)
+		}
+	})
+
+	t.Run("version parameter", func(t *testing.T) {
+		evaluate, err := NewParameterizedExpression(`app_version >= :minimum`)
+		assert.NoError(t, err)
+
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("app_version", mustParse("2.0.0")))
+
+		params := NewParams()
+		assert.NoError(t, params.Set("minimum", mustParse("1.5.0")))
+
+		result, err := evaluate(data, params)
+		assert.NoError(t, err)
+		assert.True(t, result)
+	})
+}
+
+func TestData_Version(t *testing.T) {
+
+	version, err := ParseVersion("1.2.3")
+	assert.NoError(t, err)
+
+	t.Run("dereferences version pointers", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddKeyValue("app_version", &version))
+		value, err := data.Find("app_version")
+		if assert.NoError(t, err) {
+			assert.Equal(t, version, value)
+		}
+		assert.Error(t, data.AddKeyValue("os_version", (*Version)(nil)))
+	})
+
+	t.Run("version fields are supported", func(t *testing.T) {
+		data := NewData()
+		assert.NoError(t, data.AddStruct(struct {
+			App *Version `json:"app"`
+			OS  Version  `json:"os"`
+		}{
+			App: &version,
+			OS:  version,
+		}))
+
+		for _, key := range []string{"app", "os"} {
+			value, err := data.Find(key)
+			if assert.NoError(t, err) {
+				assert.Equal(t, version, value)
+			}
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package boule
+
+// A Visitor's Visit method is invoked for each node encountered by Walk. If the result visitor w is
+// not nil, Walk visits each of the children of node with the visitor w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: it starts by calling v.Visit(node); node must not be
+// nil. If the visitor w returned by v.Visit(node) is not nil, Walk is invoked recursively with
+// visitor w for each of the non-nil children of node, in source order, followed by a call of
+// w.Visit(nil). The values of the bindings of a let expression are visited before its body.
+func Walk(v Visitor, node Node) {
+
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *GroupingExpression:
+		Walk(v, n.Node)
+	case *UnaryExpression:
+		Walk(v, n.Node)
+	case *BinaryExpression:
+		Walk(v, n.left)
+		Walk(v, n.right)
+	case *LetExpression:
+		for _, b := range n.bindings {
+			Walk(v, b.value)
+		}
+		Walk(v, n.Node)
+	case *ListExpression:
+		for _, element := range n.elements {
+			Walk(v, element)
+		}
+	case *CallExpression:
+		for _, argument := range n.arguments {
+			Walk(v, argument)
+		}
+	case *BetweenExpression:
+		Walk(v, n.value)
+		Walk(v, n.low)
+		Walk(v, n.high)
+	case *RangeExpression:
+		Walk(v, n.low)
+		Walk(v, n.high)
+	case *MatchExpression:
+		Walk(v, n.value)
+		Walk(v, n.pattern)
+	case *TypeTestExpression:
+		Walk(v, n.value)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: it starts by calling f(node); node must not be
+// nil. If f returns true, Inspect invokes f recursively for each of the non-nil children of node,
+// followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
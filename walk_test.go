@@ -0,0 +1,142 @@
+package boule
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+
+	t.Run("node spans cover their source", func(t *testing.T) {
+		for _, input := range []string{
+			`speed > 10 && !(name == 'Europa')`,
+			`  let limit = 20 in  speed < limit`,
+			`port in [22, 80, 443]`,
+			`reading between 10 and 20 || reading is null`,
+			`lower(name) like 'eu%' && client in cidr'10.0.0.0/8'`,
+			`size > 512MB && ratio <= 20% && speed in 1..<10`,
+			`version >= :minimum`,
+		} {
+			ast, err := Parse(input)
+			if !assert.NoError(t, err, input) {
+				continue
+			}
+			assert.Equal(t, len(input), ast.Root().End(), input)
+			Inspect(ast.Root(), func(node Node) bool {
+				if node != nil {
+					assert.True(t, node.Pos() >= 0 && node.Pos() < node.End() && node.End() <= len(input),
+						"%T %d:%d in %q", node, node.Pos(), node.End(), input)
+				}
+				return true
+			})
+		}
+	})
+
+	t.Run("accessors expose the parsed expression", func(t *testing.T) {
+		input := `speed > 10 && !(name == 'Europa')`
+		ast, err := Parse(input)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		and, ok := ast.Root().(*BinaryExpression)
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.Equal(t, AND, and.Operator())
+		assert.Equal(t, "&&", input[and.OperatorPos():and.OperatorPos()+2])
+
+		greater := and.Left().(*BinaryExpression)
+		assert.Equal(t, GREATER, greater.Operator())
+		assert.Equal(t, "speed", greater.Left().(*LiteralIdent).Name())
+		assert.Equal(t, big.NewInt(10), greater.Right().(*LiteralInteger).Value())
+		assert.Equal(t, "speed > 10", input[greater.Pos():greater.End()])
+
+		not := and.Right().(*UnaryExpression)
+		assert.Equal(t, NOT, not.Operator())
+		grouping := not.Operand().(*GroupingExpression)
+		assert.Equal(t, "(name == 'Europa')", input[grouping.Pos():grouping.End()])
+		equal := grouping.Inner().(*BinaryExpression)
+		assert.Equal(t, "Europa", equal.Right().(*LiteralString).Value())
+		assert.Equal(t, "'Europa'", input[equal.Right().Pos():equal.Right().End()])
+	})
+
+	t.Run("identifiers are resolved to let bindings", func(t *testing.T) {
+		ast, err := Parse(`let limit = max(10, :floor) in speed < limit`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		let := ast.Root().(*LetExpression)
+		if assert.Len(t, let.Bindings(), 1) {
+			binding := let.Bindings()[0]
+			assert.Equal(t, "limit", binding.Name())
+			call := binding.Value().(*CallExpression)
+			assert.Equal(t, "max", call.Name())
+			if assert.Len(t, call.Arguments(), 2) {
+				assert.Equal(t, ":floor", call.Arguments()[1].(*Parameter).Name())
+			}
+
+			less := let.Body().(*BinaryExpression)
+			assert.Nil(t, less.Left().(*LiteralIdent).Binding())
+			assert.Same(t, binding, less.Right().(*LiteralIdent).Binding())
+		}
+	})
+
+	t.Run("visits children in source order", func(t *testing.T) {
+		ast, err := Parse(`let a = x in a == 1 || y between 2 and 3 || z is string || [4, abs(5)] glob '*'`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var visited []string
+		Inspect(ast.Root(), func(node Node) bool {
+			switch n := node.(type) {
+			case *LiteralIdent:
+				visited = append(visited, n.Name())
+			case *LiteralInteger:
+				visited = append(visited, n.Value().String())
+			}
+			return true
+		})
+		assert.Equal(t, []string{"x", "a", "1", "y", "2", "3", "z", "4", "5"}, visited)
+	})
+
+	t.Run("stops descending when the visitor returns nil", func(t *testing.T) {
+		ast, err := Parse(`a && (b || c) && !d`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var visited []string
+		Inspect(ast.Root(), func(node Node) bool {
+			if ident, ok := node.(*LiteralIdent); ok {
+				visited = append(visited, ident.Name())
+			}
+			_, grouping := node.(*GroupingExpression)
+			return !grouping
+		})
+		assert.Equal(t, []string{"a", "d"}, visited)
+	})
+
+	t.Run("calls Visit with nil after the children", func(t *testing.T) {
+		ast, err := Parse(`a && !b`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var trace []string
+		Inspect(ast.Root(), func(node Node) bool {
+			trace = append(trace, fmt.Sprintf("%T", node))
+			return true
+		})
+		assert.Equal(t, []string{
+			"*boule.BinaryExpression",
+			"*boule.LiteralIdent", "<nil>",
+			"*boule.UnaryExpression", "*boule.LiteralIdent", "<nil>", "<nil>",
+			"<nil>",
+		}, trace)
+	})
+}